@@ -0,0 +1,898 @@
+// Package asterix decodes and encodes EUROCONTROL ASTERIX surveillance data
+// using category specifications written in YAML. Decode turns a datagram
+// into a Message of data blocks and records, whose items are held in typed
+// structs for CAT 021, 034, 048 and 062 and keyed by name otherwise. Detect
+// scores how likely a payload is ASTERIX, and Encode turns a Message, or its
+// JSON form, back into octets.
+//
+// The specifications of the supported categories are embedded. LoadSpecDir
+// and LoadExpansionDir add site-specific ones; they change state shared by
+// every decoder and must be called before decoding starts.
+package asterix
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Message represents a decoded ASTERIX datagram, which may carry several
+// data blocks of different categories
+type Message struct {
+	Blocks   []*Block `json:"blocks"`
+	Trailing string   `json:"trailing,omitempty"` // base64 of octets that do not form a data block
+	Err      error    `json:"-"`                  // a *LengthError or *TrailingError, logged as parse_error
+}
+
+// Block represents a single ASTERIX data block
+type Block struct {
+	Category    int       `json:"category"`
+	Edition     string    `json:"edition,omitempty"`
+	Offset      int       `json:"offset"` // position of the block in the datagram
+	Length      int       `json:"length"`
+	Records     []*Record `json:"records"`
+	Raw         string    `json:"raw,omitempty"` // base64 body of unsupported blocks
+	Err         error     `json:"-"`             // a *RecordError for the record that stopped decoding, logged as parse_error
+	Unsupported bool      `json:"unsupported,omitempty"`
+}
+
+// Record represents a single record within a data block
+type Record struct {
+	Index       int                    `json:"index"`
+	Offset      int                    `json:"offset"` // position of the record in the datagram
+	Length      int                    `json:"length"`
+	FSPEC       string                 `json:"fspec"`
+	UAP         string                 `json:"uap,omitempty"`         // UAP chosen for categories with several
+	Items       map[string]interface{} `json:"data_items,omitempty"`  // categories without a typed record, keyed by item name
+	Times       map[string]*Time       `json:"times,omitempty"`       // absolute times of time-of-day items, keyed by item name
+	Annotations map[string]interface{} `json:"annotations,omitempty"` // values attached by the application, never by Decode
+	Err         error                  `json:"-"`                     // the item errors, logged as parse_error
+
+	typed interface{} // the typed record, such as *CAT048Record, of categories that have one
+}
+
+// Time is a time of day item placed on the UTC time line
+type Time struct {
+	UTC     string  `json:"utc"`
+	Latency float64 `json:"latency_s"` // receive time minus the item time, in seconds
+}
+
+// Bulk summarises a bulk item, such as CAT 240 video cells, that is too
+// large to include in a log entry
+type Bulk struct {
+	Count     int    `json:"count"`  // number of elements
+	Octets    int    `json:"octets"` // size of the elements
+	File      string `json:"file,omitempty"`
+	Offset    int64  `json:"file_offset,omitempty"` // position of the octets in file
+	FileError string `json:"file_error,omitempty"`
+
+	data []byte
+}
+
+// Data returns the octets of the elements of a decoded bulk item, or nil
+// for one unmarshalled from JSON
+func (b *Bulk) Data() []byte {
+	return b.data
+}
+
+// MarshalJSON adds the message error as parse_error
+func (m *Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	return json.Marshal(struct {
+		*message
+		ParseError string `json:"parse_error,omitempty"`
+	}{(*message)(m), errorText(m.Err)})
+}
+
+// MarshalJSON adds the block error as parse_error
+func (b *Block) MarshalJSON() ([]byte, error) {
+	type block Block
+	return json.Marshal(struct {
+		*block
+		ParseError string `json:"parse_error,omitempty"`
+	}{(*block)(b), errorText(b.Err)})
+}
+
+// MarshalJSON adds the record error as parse_error, and logs the typed
+// record as the data_items of categories that have one. The fields after
+// data_items are repeated to keep their order.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	var items interface{}
+	switch {
+	case r.typed != nil:
+		items = r.typed
+	case len(r.Items) > 0:
+		items = r.Items
+	}
+
+	type record Record
+	return json.Marshal(struct {
+		*record
+		Items       interface{}            `json:"data_items,omitempty"`
+		Times       map[string]*Time       `json:"times,omitempty"`
+		Annotations map[string]interface{} `json:"annotations,omitempty"`
+		ParseError  string                 `json:"parse_error,omitempty"`
+	}{(*record)(r), items, r.Times, r.Annotations, errorText(r.Err)})
+}
+
+// Decode decodes every data block of an ASTERIX datagram using the chosen
+// editions, or the default edition of every category when editions is nil.
+// Decoding carries on past anything whose length is known, so the message
+// holds everything that could be decoded even when err is not nil. err is
+// the first error in the datagram: a *RecordError for a record, or a
+// *LengthError or *TrailingError for the framing. The errors of every
+// block and record are also kept in their Err fields.
+func Decode(payload []byte, editions *Editions) (msg *Message, err error) {
+	msg = decodeMessage(payload, editions)
+	return msg, msg.firstError()
+}
+
+// firstError returns the first error of the records, in datagram order,
+// followed by the framing error
+func (m *Message) firstError() error {
+	for _, block := range m.Blocks {
+		for i, record := range block.Records {
+			if record.Err == nil {
+				continue
+			}
+			if block.Err != nil && i == len(block.Records)-1 {
+				return block.Err
+			}
+			return &RecordError{Index: record.Index, Offset: record.Offset, Err: record.Err}
+		}
+	}
+	return m.Err
+}
+
+// Editions chooses the specification edition of each category, optionally
+// overridden per data source. A nil *Editions chooses the default edition,
+// the latest loaded, of every category.
+type Editions struct {
+	categories map[int]string
+	sources    map[uint16]map[int]string // keyed by SAC<<8 | SIC
+}
+
+// NewEditions chooses the edition of each category in categories, checking
+// that every edition has been loaded. Other categories use their default
+// edition.
+func NewEditions(categories map[int]string) (*Editions, error) {
+	if err := checkEditions(categories); err != nil {
+		return nil, err
+	}
+	return &Editions{
+		categories: categories,
+		sources:    make(map[uint16]map[int]string),
+	}, nil
+}
+
+// SetSource overrides the editions of the data blocks whose first record
+// comes from the data source with a SAC/SIC, checking that every edition has
+// been loaded
+func (e *Editions) SetSource(sac, sic int, categories map[int]string) error {
+	if err := checkEditions(categories); err != nil {
+		return err
+	}
+	e.sources[uint16(sac)<<8|uint16(sic)] = categories
+	return nil
+}
+
+// checkEditions checks that every edition of a category map is loaded
+func checkEditions(categories map[int]string) error {
+	for category, edition := range categories {
+		if LookupSpec(category, edition) == nil {
+			return fmt.Errorf("CAT %03d edition %s is not loaded", category, edition)
+		}
+	}
+	return nil
+}
+
+// spec returns the specification for a data block of category whose records
+// start at body. A source override applies when the first record carries a
+// data source identifier, which every category places at FRN 1.
+func (e *Editions) spec(category int, body []byte) *Spec {
+	if e == nil {
+		return LookupSpec(category, "")
+	}
+
+	edition := e.categories[category]
+	if len(e.sources) > 0 {
+		fspec, n := parseFSPEC(body)
+		if len(fspec) > 0 && fspec[0]&0x80 != 0 && len(body) >= n+2 {
+			source := binary.BigEndian.Uint16(body[n : n+2])
+			if override, ok := e.sources[source][category]; ok {
+				edition = override
+			}
+		}
+	}
+
+	return LookupSpec(category, edition)
+}
+
+// ResolveTimes adds the absolute UTC time and the latency of every
+// time-of-day item, taking the date from the receive time
+func (m *Message) ResolveTimes(received time.Time) {
+	for _, block := range m.Blocks {
+		spec := LookupSpec(block.Category, block.Edition)
+		if block.Unsupported || spec == nil {
+			continue
+		}
+
+		for _, record := range block.Records {
+			for _, item := range spec.Items {
+				if !item.timeOfDay {
+					continue
+				}
+				seconds, ok := record.Items[item.Name].(float64)
+				if record.typed != nil {
+					seconds, ok = spec.typed.float(record.typed, item)
+				}
+				if !ok {
+					continue
+				}
+
+				t := timeOfDayUTC(seconds, received)
+				if record.Times == nil {
+					record.Times = make(map[string]*Time)
+				}
+				record.Times[item.Name] = &Time{
+					UTC:     t.Format(time.RFC3339Nano),
+					Latency: math.Round(received.Sub(t).Seconds()*1000) / 1000,
+				}
+			}
+		}
+	}
+}
+
+// timeOfDayUTC places seconds since midnight on the UTC day that brings them
+// closest to the receive time, so a time just before midnight received just
+// after it falls on the previous day
+func timeOfDayUTC(seconds float64, received time.Time) time.Time {
+	received = received.UTC()
+	midnight := time.Date(received.Year(), received.Month(), received.Day(), 0, 0, 0, 0, time.UTC)
+	t := midnight.Add(time.Duration(seconds * float64(time.Second)))
+
+	switch diff := t.Sub(received); {
+	case diff > 12*time.Hour:
+		t = t.AddDate(0, 0, -1)
+	case diff < -12*time.Hour:
+		t = t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// Detect scores how likely the payload is ASTERIX, from 0 when its structure
+// rules it out to 1 when every block decodes exactly with a known UAP. The
+// data blocks must fill the payload exactly, every record of a known
+// category must use FRNs defined in its UAP and end where the next begins,
+// and only the listed categories are accepted when categories is not nil.
+// editions chooses the specifications as for Decode.
+func Detect(payload []byte, editions *Editions, categories map[int]bool) float64 {
+	score, _ := DetectMessage(payload, editions, categories)
+	return score
+}
+
+// DetectMessage scores a payload as Detect does and also returns the message
+// decoded to score it, which is what Decode returns for the payload with the
+// same editions. The message is nil when the framing rules the payload out
+// before anything is decoded.
+func DetectMessage(payload []byte, editions *Editions, categories map[int]bool) (float64, *Message) {
+	// Check the framing before decoding anything
+	offset := 0
+	for offset < len(payload) {
+		if len(payload)-offset < 3 {
+			return 0, nil
+		}
+		category := int(payload[offset])
+		length := int(binary.BigEndian.Uint16(payload[offset+1 : offset+3]))
+		if category == 0 || length < 4 || offset+length > len(payload) {
+			return 0, nil
+		}
+		if categories != nil && !categories[category] {
+			return 0, nil
+		}
+		offset += length
+	}
+	if offset == 0 {
+		return 0, nil
+	}
+
+	// Blocks of known categories must decode exactly. Blocks without a
+	// specification only have their framing checked, and items with bad
+	// content weaken the score.
+	msg := decodeMessage(payload, editions)
+	total := 0.0
+	for _, block := range msg.Blocks {
+		switch {
+		case block.Unsupported:
+			total += 0.5
+		case block.Err != nil || len(block.Records) == 0 || hasEmptyFSPEC(block):
+			return 0, msg
+		case hasRecordErrors(block):
+			total += 0.75
+		default:
+			total++
+		}
+	}
+
+	return math.Round(total/float64(len(msg.Blocks))*100) / 100, msg
+}
+
+// hasRecordErrors reports whether any record of a block has content errors
+func hasRecordErrors(block *Block) bool {
+	for _, record := range block.Records {
+		if record.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEmptyFSPEC reports whether any record of a block has an FSPEC octet
+// announcing no items after the first, or no items at all, which encoders
+// never produce
+func hasEmptyFSPEC(block *Block) bool {
+	for _, record := range block.Records {
+		fspec, err := base64.StdEncoding.DecodeString(record.FSPEC)
+		if err != nil || len(fspec) == 0 || fspec[len(fspec)-1] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeMessage decodes every data block in an ASTERIX datagram using the
+// chosen editions, or the defaults when nil
+func decodeMessage(payload []byte, editions *Editions) *Message {
+	msg := &Message{
+		Blocks: make([]*Block, 0, 1),
+	}
+
+	offset := 0
+	for len(payload)-offset >= 3 {
+		length := int(binary.BigEndian.Uint16(payload[offset+1 : offset+3]))
+		if length < 3 || offset+length > len(payload) {
+			msg.Err = &LengthError{Offset: offset, Length: length, Remaining: len(payload) - offset}
+			break
+		}
+
+		msg.Blocks = append(msg.Blocks, decodeBlock(payload[offset:offset+length], offset, editions))
+		offset += length
+	}
+
+	if offset < len(payload) {
+		msg.Trailing = base64.StdEncoding.EncodeToString(payload[offset:])
+		if msg.Err == nil {
+			msg.Err = &TrailingError{Offset: offset, Count: len(payload) - offset}
+		}
+	}
+
+	return msg
+}
+
+// decodeBlock decodes one data block, starting with its CAT/LEN header
+func decodeBlock(data []byte, offset int, editions *Editions) *Block {
+	block := &Block{
+		Category: int(data[0]),
+		Offset:   offset,
+		Length:   len(data),
+		Records:  make([]*Record, 0),
+	}
+
+	spec := editions.spec(block.Category, data[3:])
+	if spec == nil {
+		block.Unsupported = true
+		block.Raw = base64.StdEncoding.EncodeToString(data[3:])
+		return block
+	}
+	block.Edition = spec.Edition
+
+	// Parse records starting after the header. Each record must end exactly
+	// where the next begins, so decoding stops at the first record whose
+	// length cannot be determined.
+	pos := 3
+	for pos < len(data) {
+		record, err := decodeRecord(data[pos:], spec)
+		record.Index = len(block.Records)
+		record.Offset = offset + pos
+		block.Records = append(block.Records, record)
+
+		if err != nil {
+			block.Err = &RecordError{Index: record.Index, Offset: record.Offset, Undecoded: len(data) - pos, Err: err}
+			break
+		}
+
+		pos += record.Length
+	}
+
+	return block
+}
+
+// decodeRecord decodes one record using the category UAP. Errors in items
+// of known length are reported in the record's Err; a returned error means
+// the record length, and so the start of the next record, is unknown.
+func decodeRecord(data []byte, spec *Spec) (*Record, error) {
+	record := &Record{}
+
+	// Parse FSPEC (Field Specification)
+	fspec, fspecLen := parseFSPEC(data)
+	if fspecLen == 0 || fspec[fspecLen-1]&0x01 != 0 {
+		record.Err = ErrUnterminatedFSPEC
+		return record, ErrUnterminatedFSPEC
+	}
+
+	record.FSPEC = base64.StdEncoding.EncodeToString(data[:fspecLen])
+	offset := fspecLen
+
+	// Decode data items in FRN order, into the typed record of categories
+	// that have one and into a map otherwise
+	var dataItems map[string]interface{}
+	var typed reflect.Value
+	if spec.typed != nil {
+		typed = reflect.New(spec.typed.record)
+	} else {
+		dataItems = make(map[string]interface{})
+	}
+	decoded := 0
+	var itemErrors []error
+	frn := 1 // Field Reference Number
+
+	// Categories with several UAPs start with the default one and switch
+	// once the selector item has been decoded
+	uap := spec.UAP
+	if spec.Selector != nil {
+		record.UAP = spec.Selector.Default
+	}
+
+	// Process ALL FSPEC bytes (each byte has 7 data item bits + 1 FX bit)
+	for byteIdx := 0; byteIdx < len(fspec); byteIdx++ {
+		fspecByte := fspec[byteIdx]
+		for bitIdx := 7; bitIdx >= 1; bitIdx-- { // bits 7-1 (bit 0 is FX - extension bit)
+			if fspecByte&(1<<bitIdx) != 0 {
+				item := spec.lookup(uap, frn)
+				if item == nil {
+					err := &FRNError{FRN: frn, Category: spec.Category, Edition: spec.Edition, UAP: record.UAP}
+					record.keep(dataItems, typed, decoded)
+					return record.fail(offset, itemErrors, err)
+				}
+
+				var value interface{}
+				var bytesRead int
+				var err error
+				if typed.IsValid() {
+					bytesRead, err = spec.typed.decode(typed.Elem(), item, data[offset:])
+				} else {
+					value, bytesRead, err = decodeDataItem(data[offset:], item)
+				}
+				if err != nil {
+					err = &ItemError{Category: spec.Category, ID: item.ID, Err: err}
+					if !isContentError(err) {
+						record.keep(dataItems, typed, decoded)
+						return record.fail(offset, itemErrors, err)
+					}
+					itemErrors = append(itemErrors, err)
+				}
+				if dataItems != nil {
+					dataItems[item.Name] = value
+				}
+				decoded++
+				offset += bytesRead
+
+				if spec.Selector != nil && frn == spec.Selector.frn {
+					record.UAP, uap = spec.selectUAP(value)
+				}
+			}
+			frn++
+		}
+	}
+
+	record.keep(dataItems, typed, decoded)
+	record.Length = offset
+	record.Err = joinErrors(itemErrors)
+
+	return record, nil
+}
+
+// keep stores the items decoded into a map or a typed record, unless there
+// are none
+func (r *Record) keep(items map[string]interface{}, typed reflect.Value, count int) {
+	switch {
+	case count == 0:
+	case typed.IsValid():
+		r.typed = typed.Interface()
+	default:
+		r.Items = items
+	}
+}
+
+// fail records the length and errors of a record ended by a fatal error and
+// returns the error
+func (r *Record) fail(length int, itemErrors []error, err error) (*Record, error) {
+	r.Length = length
+	r.Err = joinErrors(append(itemErrors, err))
+	return r, err
+}
+
+// parseFSPEC parses the Field Specification (variable length bitmap)
+func parseFSPEC(data []byte) ([]byte, int) {
+	fspec := make([]byte, 0)
+
+	for i := 0; i < len(data); i++ {
+		fspec = append(fspec, data[i])
+		// Check FX bit (bit 0) - if 0, this is the last FSPEC byte
+		if data[i]&0x01 == 0 {
+			return fspec, i + 1
+		}
+		// Prevent infinite loop on malformed data
+		if i >= 10 {
+			return fspec, i + 1
+		}
+	}
+
+	return fspec, len(fspec)
+}
+
+// decodeDataItem decodes a data item according to its definition and returns
+// the decoded value and the exact number of octets it occupies
+func decodeDataItem(data []byte, item *Item) (interface{}, int, error) {
+	switch item.Format {
+	case FormatFixed:
+		if len(data) < item.Length {
+			return nil, 0, truncated(item.Length, len(data))
+		}
+		return decodeFields(item.Fields, data[:item.Length]), item.Length, nil
+
+	case FormatExtended:
+		return decodeExtendedItem(data, item)
+
+	case FormatRepetitive:
+		size, err := repetitiveSize(data, item)
+		if err != nil {
+			return nil, 0, err
+		}
+		if item.Bulk {
+			return &Bulk{Count: int(data[0]), Octets: size - 1, data: data[1:size]}, size, nil
+		}
+		if item.isString() {
+			return strings.TrimRight(string(data[1:size]), " \x00"), size, nil
+		}
+		values := make([]interface{}, 0, int(data[0]))
+		for offset := 1; offset < size; offset += item.Length {
+			element := data[offset : offset+item.Length]
+			value := decodeFields(item.Fields, element)
+			if item.ModeSMB {
+				if bds := decodeBDS(element[:7], int(element[7]>>4), int(element[7]&0x0f)); bds != nil {
+					value.(map[string]interface{})[bdsKey] = bds
+				}
+			}
+			values = append(values, value)
+		}
+		return values, size, nil
+
+	case FormatExplicit:
+		size, err := explicitSize(data)
+		if err != nil {
+			return nil, 0, err
+		}
+		raw := base64.StdEncoding.EncodeToString(data[1:size])
+		if item.Content == nil {
+			return raw, size, nil
+		}
+
+		// The length indicator bounds the content, so a bad definition or
+		// corrupt content only affects this item
+		value, n, err := decodeDataItem(data[1:size], item.Content)
+		if err == nil && n != size-1 {
+			err = fmt.Errorf("content uses %d of %d octets", n, size-1)
+		}
+		if err != nil {
+			return raw, size, &contentError{err}
+		}
+		return value, size, nil
+
+	case FormatCompound:
+		return decodeCompoundItem(data, item)
+	}
+
+	return nil, 0, fmt.Errorf("unknown format %q", item.Format)
+}
+
+// repetitiveSize returns the octets of a repetitive item, including the
+// REP factor
+func repetitiveSize(data []byte, item *Item) (int, error) {
+	if len(data) < 1 {
+		return 0, truncated(1, 0)
+	}
+	size := 1 + int(data[0])*item.Length
+	if len(data) < size {
+		return 0, truncated(size, len(data))
+	}
+	return size, nil
+}
+
+// isString reports whether a repetitive item repeats a single character,
+// making it a variable-length string
+func (it *Item) isString() bool {
+	return it.Length == 1 && len(it.Fields) == 1 && it.Fields[0].Type == FieldASCII
+}
+
+// explicitSize returns the octets of an explicit item, including the length
+// indicator
+func explicitSize(data []byte) (int, error) {
+	if len(data) < 1 {
+		return 0, truncated(1, 0)
+	}
+	size := int(data[0])
+	if size < 1 {
+		return 0, fmt.Errorf("invalid length indicator 0")
+	}
+	if len(data) < size {
+		return 0, truncated(size, len(data))
+	}
+	return size, nil
+}
+
+// decodeExtendedItem decodes an item made of FX-chained parts
+func decodeExtendedItem(data []byte, item *Item) (interface{}, int, error) {
+	values := make(map[string]interface{})
+	var repeated []interface{}
+	offset, extension, err := walkExtended(data, item, func(part int, chunk []byte) {
+		if item.Repeat {
+			repeated = append(repeated, decodeFields(item.Parts[part].Fields, chunk))
+		} else {
+			decodeFieldsInto(values, item.Parts[part].Fields, chunk)
+		}
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(item.Parts) == 0 {
+		return base64.StdEncoding.EncodeToString(data[:offset]), offset, nil
+	}
+	if extension >= 0 {
+		values["extension"] = base64.StdEncoding.EncodeToString(data[extension:offset])
+	}
+	if item.Repeat {
+		return repeated, offset, nil
+	}
+	return values, offset, nil
+}
+
+// walkExtended calls visit with the index and octets of each defined part of
+// an extended item, the last part repeating for items that repeat. It
+// returns the octets of the item and the offset of the parts beyond the
+// definition, or -1 when there are none.
+func walkExtended(data []byte, item *Item, visit func(part int, chunk []byte)) (int, int, error) {
+	offset := 0
+	extension := -1
+
+	for part := 0; ; part++ {
+		index := -1
+		switch {
+		case part < len(item.Parts):
+			index = part
+		case item.Repeat:
+			index = len(item.Parts) - 1
+		}
+
+		length := 1
+		if index >= 0 {
+			length = item.Parts[index].Length
+		}
+		if len(data) < offset+length {
+			return 0, 0, truncated(offset+length, len(data))
+		}
+
+		chunk := data[offset : offset+length]
+		offset += length
+
+		if index >= 0 {
+			visit(index, chunk)
+		} else if extension < 0 {
+			// Parts beyond the definition keep their octets so nothing is lost
+			extension = offset - length
+		}
+
+		if chunk[length-1]&0x01 == 0 {
+			return offset, extension, nil
+		}
+	}
+}
+
+// decodeCompoundItem decodes an item made of a primary subfield bitmap and
+// the subfields it announces
+func decodeCompoundItem(data []byte, item *Item) (interface{}, int, error) {
+	values := make(map[string]interface{})
+	offset, err := walkCompound(data, item, func(index int, data []byte) (int, error) {
+		sub := item.Subfields[index]
+		value, n, err := decodeDataItem(data, sub)
+		values[sub.Name] = value
+		return n, err
+	})
+	if err != nil && !isContentError(err) {
+		return nil, 0, err
+	}
+	return values, offset, err
+}
+
+// walkCompound calls decode with the index of each subfield the primary
+// subfield bitmap of a compound item announces and the octets from its
+// start, and returns the octets of the item. Subfields whose content could
+// not be decoded are kept, and the first such error is returned at the end.
+func walkCompound(data []byte, item *Item, decode func(index int, data []byte) (int, error)) (int, error) {
+	primary, offset := parseFSPEC(data)
+	if offset == 0 || primary[offset-1]&0x01 != 0 {
+		return 0, fmt.Errorf("unterminated primary subfield")
+	}
+
+	var subErr error
+	index := 0
+	for _, b := range primary {
+		for bit := 7; bit >= 1; bit-- {
+			if b&(1<<bit) != 0 {
+				if index >= len(item.Subfields) || item.Subfields[index] == nil {
+					return 0, fmt.Errorf("undefined subfield %d", index+1)
+				}
+
+				n, err := decode(index, data[offset:])
+				if err != nil {
+					err = fmt.Errorf("subfield %s: %w", item.Subfields[index].Name, err)
+					if !isContentError(err) {
+						return 0, err
+					}
+					if subErr == nil {
+						subErr = &contentError{err}
+					}
+				}
+				offset += n
+			}
+			index++
+		}
+	}
+
+	return offset, subErr
+}
+
+// decodeFields decodes a fixed layout. Layouts with a single named field
+// yield that value directly; undefined layouts yield the raw octets.
+func decodeFields(fields []*Field, data []byte) interface{} {
+	if len(fields) == 0 {
+		return base64.StdEncoding.EncodeToString(data)
+	}
+
+	var named *Field
+	count := 0
+	for _, f := range fields {
+		if f.Type != FieldSpare {
+			named = f
+			count++
+		}
+	}
+	if count == 1 {
+		bit := 0
+		for _, f := range fields {
+			if f == named {
+				return named.decode(data, bit)
+			}
+			bit += f.Bits
+		}
+	}
+
+	values := make(map[string]interface{}, count)
+	decodeFieldsInto(values, fields, data)
+	return values
+}
+
+// decodeFieldsInto decodes each named field of a layout into values
+func decodeFieldsInto(values map[string]interface{}, fields []*Field, data []byte) {
+	bit := 0
+	for _, f := range fields {
+		if f.Type != FieldSpare {
+			values[f.Name] = f.decode(data, bit)
+		}
+		bit += f.Bits
+	}
+}
+
+// decode extracts the field value starting at the given bit offset
+func (f *Field) decode(data []byte, bit int) interface{} {
+	switch {
+	case f.Type == FieldBool:
+		return f.flag(data, bit)
+	case f.isText():
+		return f.text(data, bit)
+	case f.scale != 0:
+		return f.scaled(data, bit)
+	}
+	return int(f.integer(data, bit))
+}
+
+// isText reports whether a field decodes to a string
+func (f *Field) isText() bool {
+	switch f.Type {
+	case FieldOctal, FieldHex, FieldICAO6, FieldASCII, FieldRaw:
+		return true
+	}
+	return false
+}
+
+// flag reads a bool field
+func (f *Field) flag(data []byte, bit int) bool {
+	return (readBits(data, bit, 1) != 0) != f.Invert
+}
+
+// text reads a field that decodes to a string
+func (f *Field) text(data []byte, bit int) string {
+	switch f.Type {
+	case FieldOctal:
+		return fmt.Sprintf("%0*o", f.Bits/3, readBits(data, bit, f.Bits))
+	case FieldHex:
+		return fmt.Sprintf("%0*X", f.Bits/4, readBits(data, bit, f.Bits))
+	case FieldICAO6:
+		return decodeICAO6(data, bit, f.Bits/6)
+	case FieldASCII:
+		chars := make([]byte, f.Bits/8)
+		for i := range chars {
+			chars[i] = byte(readBits(data, bit+i*8, 8))
+		}
+		return strings.TrimRight(string(chars), " \x00")
+	}
+
+	octets := make([]byte, f.Bits/8)
+	for i := range octets {
+		octets[i] = byte(readBits(data, bit+i*8, 8))
+	}
+	return base64.StdEncoding.EncodeToString(octets)
+}
+
+// integer reads an int or uint field, extending the sign of int fields
+func (f *Field) integer(data []byte, bit int) int64 {
+	raw := readBits(data, bit, f.Bits)
+	value := int64(raw)
+	if f.Type == FieldInt && f.Bits < 64 && raw&(1<<(f.Bits-1)) != 0 {
+		value -= 1 << f.Bits
+	}
+	return value
+}
+
+// scaled reads an int or uint field multiplied by its lsb
+func (f *Field) scaled(data []byte, bit int) float64 {
+	if f.Type == FieldInt {
+		return float64(f.integer(data, bit)) * f.scale
+	}
+	return float64(readBits(data, bit, f.Bits)) * f.scale
+}
+
+// readBits reads n bits (at most 64) starting at the given bit offset, most
+// significant bit first
+func readBits(data []byte, bit, n int) uint64 {
+	var value uint64
+	for i := bit; i < bit+n; i++ {
+		value = value<<1 | uint64(data[i/8]>>(7-uint(i%8))&0x01)
+	}
+	return value
+}
+
+// decodeICAO6 decodes a string of ICAO 6-bit characters (as used for
+// aircraft identification) starting at the given bit offset
+func decodeICAO6(data []byte, bit, count int) string {
+	const chars = "?ABCDEFGHIJKLMNOPQRSTUVWXYZ????? ???????????????0123456789??????"
+
+	result := make([]byte, count)
+	for i := range result {
+		result[i] = chars[readBits(data, bit+i*6, 6)]
+	}
+
+	// Trim trailing spaces
+	return strings.TrimRight(string(result), " ")
+}
+
+// truncated reports an item that runs past the end of the data
+func truncated(need, have int) error {
+	return &TruncatedError{Need: need, Have: have}
+}
@@ -0,0 +1,239 @@
+package asterix
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// bdsKey is the key of the decoded register added to Mode S MB elements
+const bdsKey = "bds"
+
+// BDSRegister is the typed form of a decoded Comm-B register. Only the
+// values of the register named in Register whose status bits are set are
+// present.
+type BDSRegister struct {
+	Register string `json:"register"`
+	Inferred bool   `json:"inferred,omitempty"` // the register was inferred from the content
+
+	// BDS 4,0 selected vertical intention
+	MCPSelectedAltitudeFt *float64 `json:"mcp_selected_altitude_ft,omitempty"`
+	FMSSelectedAltitudeFt *float64 `json:"fms_selected_altitude_ft,omitempty"`
+	BaroSettingMB         *float64 `json:"baro_setting_mb,omitempty"`
+	VNAVMode              *bool    `json:"vnav_mode,omitempty"`
+	AltitudeHoldMode      *bool    `json:"altitude_hold_mode,omitempty"`
+	ApproachMode          *bool    `json:"approach_mode,omitempty"`
+	TargetAltitudeSource  *float64 `json:"target_altitude_source,omitempty"`
+
+	// BDS 5,0 track and turn report
+	RollAngleDeg       *float64 `json:"roll_angle_deg,omitempty"`
+	TrueTrackDeg       *float64 `json:"true_track_deg,omitempty"`
+	GroundSpeedKt      *float64 `json:"ground_speed_kt,omitempty"`
+	TrackAngleRateDegS *float64 `json:"track_angle_rate_deg_s,omitempty"`
+	TrueAirspeedKt     *float64 `json:"true_airspeed_kt,omitempty"`
+
+	// BDS 6,0 heading and speed report
+	MagneticHeadingDeg            *float64 `json:"magnetic_heading_deg,omitempty"`
+	IndicatedAirspeedKt           *float64 `json:"indicated_airspeed_kt,omitempty"`
+	Mach                          *float64 `json:"mach,omitempty"`
+	BaroAltitudeRateFtMin         *float64 `json:"baro_altitude_rate_ft_min,omitempty"`
+	InertialVerticalVelocityFtMin *float64 `json:"inertial_vertical_velocity_ft_min,omitempty"`
+}
+
+// bdsField describes one value of a Comm-B register. Bits are numbered from
+// 1 to 56 as in the ICAO register tables; each value follows its status bit.
+type bdsField struct {
+	name        string
+	status      int     // bit flagging the value as valid
+	first, last int     // value bits, first being the sign bit of signed values
+	signed      bool    // two's complement
+	lsb         float64 // scale of numeric values, 0 for flags
+	offset      float64 // added after scaling
+	max         float64 // largest plausible magnitude when inferring the register, 0 for any
+	heading     bool    // angle reported from 0 to 360 degrees
+}
+
+// bdsRegister describes the layout of a Comm-B register
+type bdsRegister struct {
+	name     string
+	fields   []bdsField
+	reserved [][2]int // bit ranges that must be zero
+}
+
+// bdsRegisters are the registers decoded, keyed by BDS1<<4 | BDS2
+var bdsRegisters = map[int]*bdsRegister{
+	// Selected vertical intention
+	0x40: {
+		name: "4,0",
+		fields: []bdsField{
+			{name: "mcp_selected_altitude_ft", status: 1, first: 2, last: 13, lsb: 16, max: 50000},
+			{name: "fms_selected_altitude_ft", status: 14, first: 15, last: 26, lsb: 16, max: 50000},
+			{name: "baro_setting_mb", status: 27, first: 28, last: 39, lsb: 0.1, offset: 800},
+			{name: "vnav_mode", status: 48, first: 49, last: 49},
+			{name: "altitude_hold_mode", status: 48, first: 50, last: 50},
+			{name: "approach_mode", status: 48, first: 51, last: 51},
+			{name: "target_altitude_source", status: 54, first: 55, last: 56, lsb: 1},
+		},
+		reserved: [][2]int{{40, 47}, {52, 53}},
+	},
+	// Track and turn report
+	0x50: {
+		name: "5,0",
+		fields: []bdsField{
+			{name: "roll_angle_deg", status: 1, first: 2, last: 11, signed: true, lsb: 45.0 / 256, max: 50},
+			{name: "true_track_deg", status: 12, first: 13, last: 23, signed: true, lsb: 90.0 / 512, heading: true},
+			{name: "ground_speed_kt", status: 24, first: 25, last: 34, lsb: 2, max: 600},
+			{name: "track_angle_rate_deg_s", status: 35, first: 36, last: 45, signed: true, lsb: 8.0 / 256, max: 16},
+			{name: "true_airspeed_kt", status: 46, first: 47, last: 56, lsb: 2, max: 600},
+		},
+	},
+	// Heading and speed report
+	0x60: {
+		name: "6,0",
+		fields: []bdsField{
+			{name: "magnetic_heading_deg", status: 1, first: 2, last: 12, signed: true, lsb: 90.0 / 512, heading: true},
+			{name: "indicated_airspeed_kt", status: 13, first: 14, last: 23, lsb: 1, max: 500},
+			{name: "mach", status: 24, first: 25, last: 34, lsb: 2.048 / 512, max: 1},
+			{name: "baro_altitude_rate_ft_min", status: 35, first: 36, last: 45, signed: true, lsb: 32, max: 6000},
+			{name: "inertial_vertical_velocity_ft_min", status: 46, first: 47, last: 56, signed: true, lsb: 32, max: 6000},
+		},
+	},
+}
+
+// bdsInferable lists the registers tried, in order, for MB data without a
+// BDS code
+var bdsInferable = []int{0x40, 0x50, 0x60}
+
+// decodeBDS decodes the 56-bit MB field of a Comm-B reply from the register
+// given by bds1 and bds2. A register of 0,0 is inferred when exactly one of
+// the known registers holds plausible values. It returns nil for registers
+// that are not decoded.
+func decodeBDS(mb []byte, bds1, bds2 int) map[string]interface{} {
+	register, inferred := findBDSRegister(mb, bds1, bds2)
+	if register == nil {
+		return nil
+	}
+	values := map[string]interface{}{"register": register.name}
+	register.decode(mb, func(f *bdsField, value interface{}) {
+		values[f.name] = value
+	})
+	if inferred {
+		values["inferred"] = true
+	}
+	return values
+}
+
+// decodeBDSRegister decodes the MB field like decodeBDS into the typed form
+// held by typed records
+func decodeBDSRegister(mb []byte, bds1, bds2 int) *BDSRegister {
+	register, inferred := findBDSRegister(mb, bds1, bds2)
+	if register == nil {
+		return nil
+	}
+	typed := &BDSRegister{Register: register.name, Inferred: inferred}
+	v := reflect.ValueOf(typed).Elem()
+	register.decode(mb, func(f *bdsField, value interface{}) {
+		field := v.Field(bdsRegisterFields[f.name])
+		ptr := reflect.New(field.Type().Elem())
+		ptr.Elem().Set(reflect.ValueOf(value))
+		field.Set(ptr)
+	})
+	return typed
+}
+
+// bdsRegisterFields holds the index of each BDSRegister field by JSON name
+var bdsRegisterFields = jsonFields(reflect.TypeOf(BDSRegister{}))
+
+// findBDSRegister returns the register given by bds1 and bds2, or the
+// register inferred for a BDS code of 0,0 and true
+func findBDSRegister(mb []byte, bds1, bds2 int) (*bdsRegister, bool) {
+	if bds1 != 0 || bds2 != 0 {
+		return bdsRegisters[bds1<<4|bds2], false
+	}
+
+	var inferred *bdsRegister
+	for _, code := range bdsInferable {
+		if !bdsRegisters[code].decode(mb, func(*bdsField, interface{}) {}) {
+			continue
+		}
+		if inferred != nil {
+			return nil, false // ambiguous
+		}
+		inferred = bdsRegisters[code]
+	}
+	return inferred, inferred != nil
+}
+
+// decode passes set the values whose status bits are set, and reports
+// whether the content is plausible for the register: reserved bits and the
+// values of clear status bits are zero, and values are within their maxima
+func (r *bdsRegister) decode(mb []byte, set func(f *bdsField, value interface{})) bool {
+	plausible := true
+	for _, bits := range r.reserved {
+		if mbBits(mb, bits[0], bits[1]) != 0 {
+			plausible = false
+		}
+	}
+
+	valid := 0
+	for i := range r.fields {
+		f := &r.fields[i]
+		if mbBits(mb, f.status, f.status) == 0 {
+			if mbBits(mb, f.first, f.last) != 0 {
+				plausible = false
+			}
+			continue
+		}
+		valid++
+
+		value := f.decode(mb)
+		if v, ok := value.(float64); ok && f.max != 0 && math.Abs(v) > f.max {
+			plausible = false
+		}
+		set(f, value)
+	}
+
+	return plausible && valid > 0
+}
+
+// decode returns the scaled value of a field, or a bool for flags
+func (f *bdsField) decode(mb []byte) interface{} {
+	raw := mbBits(mb, f.first, f.last)
+	if f.lsb == 0 {
+		return raw != 0
+	}
+
+	n := f.last - f.first + 1
+	value := float64(raw)
+	if f.signed && raw&(1<<(n-1)) != 0 {
+		value -= float64(uint64(1) << n)
+	}
+	value = value*f.lsb + f.offset
+	if f.heading && value < 0 {
+		value += 360
+	}
+	return value
+}
+
+// mbBits reads bits first to last, numbered from 1, of the MB field
+func mbBits(mb []byte, first, last int) uint64 {
+	return readBits(mb, first-1, last-first+1)
+}
+
+// prepareModeSMB checks that a repetitive item holds 56 bits of MB data
+// followed by the BDS1 and BDS2 codes
+func (it *Item) prepareModeSMB() error {
+	if it.Format != FormatRepetitive || it.Bulk {
+		return fmt.Errorf("mode_s_mb only applies to repetitive items")
+	}
+	layout := []int{56, 4, 4}
+	if it.Length != 8 || len(it.Fields) != len(layout) {
+		return fmt.Errorf("mode_s_mb needs 56 bits of MB data, BDS1 and BDS2")
+	}
+	for i, f := range it.Fields {
+		if f.Bits != layout[i] {
+			return fmt.Errorf("mode_s_mb needs 56 bits of MB data, BDS1 and BDS2")
+		}
+	}
+	return nil
+}
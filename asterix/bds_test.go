@@ -0,0 +1,123 @@
+package asterix
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// Test decoding Comm-B registers, given or inferred
+func TestDecodeBDS(t *testing.T) {
+	tests := []struct {
+		name     string
+		mb       string
+		bds1     int
+		bds2     int
+		expected map[string]interface{}
+	}{
+		{
+			name: "Selected vertical intention",
+			mb:   "85e42f31300000",
+			bds1: 4,
+			expected: map[string]interface{}{
+				"register":                 "4,0",
+				"mcp_selected_altitude_ft": 3008.0,
+				"fms_selected_altitude_ft": 3008.0,
+				"baro_setting_mb":          1020.0,
+			},
+		},
+		{
+			name: "Track and turn report",
+			mb:   "81951536e024d4",
+			bds1: 5,
+			expected: map[string]interface{}{
+				"register":               "5,0",
+				"roll_angle_deg":         2.109375,
+				"true_track_deg":         114.2578125,
+				"ground_speed_kt":        438.0,
+				"track_angle_rate_deg_s": 0.125,
+				"true_airspeed_kt":       424.0,
+			},
+		},
+		{
+			name: "Heading and speed report",
+			mb:   "8f39f91a7e27c4",
+			bds1: 6,
+			expected: map[string]interface{}{
+				"register":                          "6,0",
+				"magnetic_heading_deg":              42.71484375,
+				"indicated_airspeed_kt":             252.0,
+				"mach":                              0.42,
+				"baro_altitude_rate_ft_min":         -1920.0,
+				"inertial_vertical_velocity_ft_min": -1920.0,
+			},
+		},
+		{
+			name: "Inferred register",
+			mb:   "85e42f31300000",
+			expected: map[string]interface{}{
+				"register":                 "4,0",
+				"inferred":                 true,
+				"mcp_selected_altitude_ft": 3008.0,
+				"fms_selected_altitude_ft": 3008.0,
+				"baro_setting_mb":          1020.0,
+			},
+		},
+		{
+			name: "No plausible register",
+			mb:   "ffffffffffffff",
+		},
+		{
+			name: "Register not decoded",
+			mb:   "85e42f31300000",
+			bds1: 2,
+			bds2: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mb, _ := hex.DecodeString(tt.mb)
+			got := decodeBDS(mb, tt.bds1, tt.bds2)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("decodeBDS() = %v, want %v", got, tt.expected)
+			}
+			for key, want := range tt.expected {
+				if w, ok := want.(float64); ok {
+					if v, ok := got[key].(float64); !ok || math.Abs(v-w) > 1e-9 {
+						t.Errorf("%s = %v, want %v", key, got[key], want)
+					}
+				} else if !reflect.DeepEqual(got[key], want) {
+					t.Errorf("%s = %v, want %v", key, got[key], want)
+				}
+			}
+		})
+	}
+}
+
+// Test that BDS registers in I048/250 are decoded and survive re-encoding
+func TestDecodeCAT048ModeSMB(t *testing.T) {
+	payload, _ := hex.DecodeString("300020" +
+		"81" + "20" + // FSPEC: FRN 1 and 10
+		"0102" + // I048/010
+		"03" + // I048/250: 3 registers
+		"85e42f31300000" + "40" +
+		"8f39f91a7e27c4" + "60" +
+		"00000000000000" + "10")
+
+	msg := decodeMessage(payload, nil)
+	if len(msg.Blocks) != 1 || len(msg.Blocks[0].Records) != 1 || msg.Blocks[0].Records[0].Err != nil {
+		t.Fatalf("Decoded %+v, want one block with one record", msg.Blocks)
+	}
+
+	elements := msg.Blocks[0].Records[0].ItemValues()["bds_register_data"].([]interface{})
+	for i, register := range []interface{}{"4,0", "6,0", nil} {
+		bds, _ := elements[i].(map[string]interface{})[bdsKey].(map[string]interface{})
+		if got := bds["register"]; got != register {
+			t.Errorf("element %d register = %v, want %v", i, got, register)
+		}
+	}
+
+	assertRoundTrip(t, payload)
+}
@@ -0,0 +1,292 @@
+package asterix
+
+// CAT021Record is a typed record of CAT 021 edition 2.4. Each field
+// marshals to the same JSON as the decoded data item.
+type CAT021Record struct {
+	DataSourceID                                *CAT021DataSourceID                                `json:"data_source_id,omitempty"`                                    // I021/010
+	TargetReportDescriptor                      *CAT021TargetReportDescriptor                      `json:"target_report_descriptor,omitempty"`                          // I021/040
+	TrackNumber                                 *int                                               `json:"track_number,omitempty"`                                      // I021/161
+	ServiceID                                   *int                                               `json:"service_id,omitempty"`                                        // I021/015
+	TimeOfApplicabilityPosition                 *float64                                           `json:"time_of_applicability_position,omitempty"`                    // I021/071
+	PositionWGS84                               *CAT021PositionWGS84                               `json:"position_wgs84,omitempty"`                                    // I021/130
+	PositionWGS84HighRes                        *CAT021PositionWGS84HighRes                        `json:"position_wgs84_high_res,omitempty"`                           // I021/131
+	TimeOfApplicabilityVelocity                 *float64                                           `json:"time_of_applicability_velocity,omitempty"`                    // I021/072
+	AirSpeed                                    *CAT021AirSpeed                                    `json:"air_speed,omitempty"`                                         // I021/150
+	TrueAirspeed                                *CAT021TrueAirspeed                                `json:"true_airspeed,omitempty"`                                     // I021/151
+	TargetAddress                               *string                                            `json:"target_address,omitempty"`                                    // I021/080
+	TimeOfMessageReceptionPosition              *float64                                           `json:"time_of_message_reception_position,omitempty"`                // I021/073
+	TimeOfMessageReceptionPositionHighPrecision *CAT021TimeOfMessageReceptionPositionHighPrecision `json:"time_of_message_reception_position_high_precision,omitempty"` // I021/074
+	TimeOfMessageReceptionVelocity              *float64                                           `json:"time_of_message_reception_velocity,omitempty"`                // I021/075
+	TimeOfMessageReceptionVelocityHighPrecision *CAT021TimeOfMessageReceptionVelocityHighPrecision `json:"time_of_message_reception_velocity_high_precision,omitempty"` // I021/076
+	GeometricHeight                             *float64                                           `json:"geometric_height,omitempty"`                                  // I021/140
+	QualityIndicators                           *CAT021QualityIndicators                           `json:"quality_indicators,omitempty"`                                // I021/090
+	MOPSVersion                                 *CAT021MOPSVersion                                 `json:"mops_version,omitempty"`                                      // I021/210
+	Mode3A                                      *string                                            `json:"mode3a,omitempty"`                                            // I021/070
+	RollAngle                                   *float64                                           `json:"roll_angle,omitempty"`                                        // I021/230
+	FlightLevel                                 *float64                                           `json:"flight_level,omitempty"`                                      // I021/145
+	MagneticHeading                             *float64                                           `json:"magnetic_heading,omitempty"`                                  // I021/152
+	TargetStatus                                *CAT021TargetStatus                                `json:"target_status,omitempty"`                                     // I021/200
+	BarometricVerticalRate                      *CAT021BarometricVerticalRate                      `json:"barometric_vertical_rate,omitempty"`                          // I021/155
+	GeometricVerticalRate                       *CAT021GeometricVerticalRate                       `json:"geometric_vertical_rate,omitempty"`                           // I021/157
+	AirborneGroundVector                        *CAT021AirborneGroundVector                        `json:"airborne_ground_vector,omitempty"`                            // I021/160
+	TrackAngleRate                              *float64                                           `json:"track_angle_rate,omitempty"`                                  // I021/165
+	TimeOfReportTransmission                    *float64                                           `json:"time_of_report_transmission,omitempty"`                       // I021/077
+	TargetIdentification                        *string                                            `json:"target_identification,omitempty"`                             // I021/170
+	EmitterCategory                             *int                                               `json:"emitter_category,omitempty"`                                  // I021/020
+	MetInformation                              *CAT021MetInformation                              `json:"met_information,omitempty"`                                   // I021/220
+	SelectedAltitude                            *CAT021SelectedAltitude                            `json:"selected_altitude,omitempty"`                                 // I021/146
+	FinalStateSelectedAltitude                  *CAT021FinalStateSelectedAltitude                  `json:"final_state_selected_altitude,omitempty"`                     // I021/148
+	TrajectoryIntent                            *CAT021TrajectoryIntent                            `json:"trajectory_intent,omitempty"`                                 // I021/110
+	ServiceManagement                           *float64                                           `json:"service_management,omitempty"`                                // I021/016
+	AircraftOperationalStatus                   *CAT021AircraftOperationalStatus                   `json:"aircraft_operational_status,omitempty"`                       // I021/008
+	SurfaceCapabilities                         *CAT021SurfaceCapabilities                         `json:"surface_capabilities,omitempty"`                              // I021/271
+	MessageAmplitude                            *int                                               `json:"message_amplitude,omitempty"`                                 // I021/132
+	ModeSMBData                                 []CAT021ModeSMBData                                `json:"mode_s_mb_data,omitempty"`                                    // I021/250
+	ACASResolutionAdvisory                      *CAT021ACASResolutionAdvisory                      `json:"acas_resolution_advisory,omitempty"`                          // I021/260
+	ReceiverID                                  *int                                               `json:"receiver_id,omitempty"`                                       // I021/400
+	DataAges                                    *CAT021DataAges                                    `json:"data_ages,omitempty"`                                         // I021/295
+	ReservedExpansion                           interface{}                                        `json:"reserved_expansion,omitempty"`                                // I021/RE
+	SpecialPurpose                              interface{}                                        `json:"special_purpose,omitempty"`                                   // I021/SP
+}
+
+// CAT021DataSourceID holds I021/010, Data Source Identification
+type CAT021DataSourceID struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT021TargetReportDescriptor holds I021/040, Target Report Descriptor
+type CAT021TargetReportDescriptor struct {
+	ATP       int    `json:"atp"`
+	ARC       int    `json:"arc"`
+	Rc        bool   `json:"rc"`
+	Rab       bool   `json:"rab"`
+	Dcr       *bool  `json:"dcr,omitempty"`
+	Gbs       *bool  `json:"gbs,omitempty"`
+	Sim       *bool  `json:"sim,omitempty"`
+	Tst       *bool  `json:"tst,omitempty"`
+	Saa       *bool  `json:"saa,omitempty"`
+	Cl        *int   `json:"cl,omitempty"`
+	Ipc       *bool  `json:"ipc,omitempty"`
+	Nogo      *bool  `json:"nogo,omitempty"`
+	CPR       *bool  `json:"cpr,omitempty"`
+	Ldpj      *bool  `json:"ldpj,omitempty"`
+	Rcf       *bool  `json:"rcf,omitempty"`
+	TbcEp     *bool  `json:"tbc_ep,omitempty"`
+	Tbc       *int   `json:"tbc,omitempty"`
+	MbcEp     *bool  `json:"mbc_ep,omitempty"`
+	Mbc       *int   `json:"mbc,omitempty"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT021PositionWGS84 holds I021/130, Position in WGS-84 Co-ordinates
+type CAT021PositionWGS84 struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT021PositionWGS84HighRes holds I021/131, High-Resolution Position in WGS-84 Co-ordinates
+type CAT021PositionWGS84HighRes struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT021AirSpeed holds I021/150, Air Speed
+type CAT021AirSpeed struct {
+	Mach  bool `json:"mach"`
+	Speed int  `json:"speed"`
+}
+
+// CAT021TrueAirspeed holds I021/151, True Airspeed
+type CAT021TrueAirspeed struct {
+	RangeExceeded bool `json:"range_exceeded"`
+	SpeedKt       int  `json:"speed_kt"`
+}
+
+// CAT021TimeOfMessageReceptionPositionHighPrecision holds I021/074, Time of Message Reception of Position-High Precision
+type CAT021TimeOfMessageReceptionPositionHighPrecision struct {
+	Fsi       int     `json:"fsi"`
+	FractionS float64 `json:"fraction_s"`
+}
+
+// CAT021TimeOfMessageReceptionVelocityHighPrecision holds I021/076, Time of Message Reception of Velocity-High Precision
+type CAT021TimeOfMessageReceptionVelocityHighPrecision struct {
+	Fsi       int     `json:"fsi"`
+	FractionS float64 `json:"fraction_s"`
+}
+
+// CAT021QualityIndicators holds I021/090, Quality Indicators
+type CAT021QualityIndicators struct {
+	NucrNacv      int    `json:"nucr_nacv"`
+	NUCpNIC       int    `json:"nucp_nic"`
+	NICBaro       *bool  `json:"nic_baro,omitempty"`
+	SIL           *int   `json:"sil,omitempty"`
+	NACp          *int   `json:"nacp,omitempty"`
+	SILSupplement *bool  `json:"sil_supplement,omitempty"`
+	Sda           *int   `json:"sda,omitempty"`
+	GVA           *int   `json:"gva,omitempty"`
+	Pic           *int   `json:"pic,omitempty"`
+	Extension     string `json:"extension,omitempty"`
+}
+
+// CAT021MOPSVersion holds I021/210, MOPS Version
+type CAT021MOPSVersion struct {
+	Vns bool `json:"vns"`
+	Vn  int  `json:"vn"`
+	Ltt int  `json:"ltt"`
+}
+
+// CAT021TargetStatus holds I021/200, Target Status
+type CAT021TargetStatus struct {
+	Icf  bool `json:"icf"`
+	Lnav bool `json:"lnav"`
+	Me   bool `json:"me"`
+	Ps   int  `json:"ps"`
+	Ss   int  `json:"ss"`
+}
+
+// CAT021BarometricVerticalRate holds I021/155, Barometric Vertical Rate
+type CAT021BarometricVerticalRate struct {
+	RangeExceeded bool    `json:"range_exceeded"`
+	RateFtMin     float64 `json:"rate_ft_min"`
+}
+
+// CAT021GeometricVerticalRate holds I021/157, Geometric Vertical Rate
+type CAT021GeometricVerticalRate struct {
+	RangeExceeded bool    `json:"range_exceeded"`
+	RateFtMin     float64 `json:"rate_ft_min"`
+}
+
+// CAT021AirborneGroundVector holds I021/160, Airborne Ground Vector
+type CAT021AirborneGroundVector struct {
+	RangeExceeded  bool    `json:"range_exceeded"`
+	GroundspeedNMS float64 `json:"groundspeed_nm_s"`
+	TrackAngleDeg  float64 `json:"track_angle_deg"`
+}
+
+// CAT021MetInformation holds I021/220, Met Information
+type CAT021MetInformation struct {
+	WindSpeed     *int     `json:"wind_speed,omitempty"`
+	WindDirection *int     `json:"wind_direction,omitempty"`
+	Temperature   *float64 `json:"temperature,omitempty"`
+	Turbulence    *int     `json:"turbulence,omitempty"`
+}
+
+// CAT021SelectedAltitude holds I021/146, Selected Altitude
+type CAT021SelectedAltitude struct {
+	Sas        bool    `json:"sas"`
+	Source     int     `json:"source"`
+	AltitudeFt float64 `json:"altitude_ft"`
+}
+
+// CAT021FinalStateSelectedAltitude holds I021/148, Final State Selected Altitude
+type CAT021FinalStateSelectedAltitude struct {
+	Mv         bool    `json:"mv"`
+	Ah         bool    `json:"ah"`
+	Am         bool    `json:"am"`
+	AltitudeFt float64 `json:"altitude_ft"`
+}
+
+// CAT021TrajectoryIntentTis holds the Trajectory Intent Status subfield of I021/110
+type CAT021TrajectoryIntentTis struct {
+	Nav       bool   `json:"nav"`
+	Nvb       bool   `json:"nvb"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT021TrajectoryIntentTid holds the Trajectory Intent Data subfield of I021/110
+type CAT021TrajectoryIntentTid struct {
+	Tca        bool    `json:"tca"`
+	Nc         bool    `json:"nc"`
+	TcpNumber  int     `json:"tcp_number"`
+	AltitudeFt float64 `json:"altitude_ft"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	PointType  int     `json:"point_type"`
+	Td         int     `json:"td"`
+	Tra        bool    `json:"tra"`
+	Toa        bool    `json:"toa"`
+	TOVS       int     `json:"tov_s"`
+	TTRNM      float64 `json:"ttr_nm"`
+}
+
+// CAT021TrajectoryIntent holds I021/110, Trajectory Intent
+type CAT021TrajectoryIntent struct {
+	Tis *CAT021TrajectoryIntentTis  `json:"tis,omitempty"`
+	Tid []CAT021TrajectoryIntentTid `json:"tid,omitempty"`
+}
+
+// CAT021AircraftOperationalStatus holds I021/008, Aircraft Operational Status
+type CAT021AircraftOperationalStatus struct {
+	RA      bool `json:"ra"`
+	Tc      int  `json:"tc"`
+	Ts      bool `json:"ts"`
+	Arv     bool `json:"arv"`
+	CdtiA   bool `json:"cdti_a"`
+	NotTcas bool `json:"not_tcas"`
+	Sa      bool `json:"sa"`
+}
+
+// CAT021SurfaceCapabilities holds I021/271, Surface Capabilities and Characteristics
+type CAT021SurfaceCapabilities struct {
+	Poa         bool   `json:"poa"`
+	CdtiS       bool   `json:"cdti_s"`
+	B2Low       bool   `json:"b2_low"`
+	Ras         bool   `json:"ras"`
+	Ident       bool   `json:"ident"`
+	LengthWidth *int   `json:"length_width,omitempty"`
+	Extension   string `json:"extension,omitempty"`
+}
+
+// CAT021ModeSMBData holds I021/250, Mode S MB Data
+type CAT021ModeSMBData struct {
+	MBData string       `json:"mb_data"`
+	Bds1   int          `json:"bds1"`
+	Bds2   int          `json:"bds2"`
+	BDS    *BDSRegister `json:"bds,omitempty"`
+}
+
+// CAT021ACASResolutionAdvisory holds I021/260, ACAS Resolution Advisory Report
+type CAT021ACASResolutionAdvisory struct {
+	Typ  int  `json:"typ"`
+	Styp int  `json:"styp"`
+	Ara  int  `json:"ara"`
+	Rac  int  `json:"rac"`
+	Rat  bool `json:"rat"`
+	Mte  bool `json:"mte"`
+	Tti  int  `json:"tti"`
+	Tid  int  `json:"tid"`
+}
+
+// CAT021DataAges holds I021/295, Data Ages
+type CAT021DataAges struct {
+	Aos *float64 `json:"aos,omitempty"`
+	Trd *float64 `json:"trd,omitempty"`
+	M3a *float64 `json:"m3a,omitempty"`
+	Qi  *float64 `json:"qi,omitempty"`
+	Ti  *float64 `json:"ti,omitempty"`
+	Mam *float64 `json:"mam,omitempty"`
+	Gh  *float64 `json:"gh,omitempty"`
+	Fl  *float64 `json:"fl,omitempty"`
+	Sal *float64 `json:"sal,omitempty"`
+	Fsa *float64 `json:"fsa,omitempty"`
+	As  *float64 `json:"as,omitempty"`
+	TAS *float64 `json:"tas,omitempty"`
+	Mh  *float64 `json:"mh,omitempty"`
+	Bvr *float64 `json:"bvr,omitempty"`
+	Gvr *float64 `json:"gvr,omitempty"`
+	Gv  *float64 `json:"gv,omitempty"`
+	Tar *float64 `json:"tar,omitempty"`
+	Tid *float64 `json:"tid,omitempty"`
+	Ts  *float64 `json:"ts,omitempty"`
+	Met *float64 `json:"met,omitempty"`
+	Roa *float64 `json:"roa,omitempty"`
+	Ara *float64 `json:"ara,omitempty"`
+	Scc *float64 `json:"scc,omitempty"`
+}
+
+// CAT021Records returns the typed records of a CAT 021 data block decoded with
+// edition 2.4
+func (b *Block) CAT021Records() ([]*CAT021Record, error) {
+	return typedRecords[CAT021Record](b, 21, "2.4")
+}
@@ -0,0 +1,133 @@
+package asterix
+
+// CAT034Record is a typed record of CAT 034 edition 1.29. Each field
+// marshals to the same JSON as the decoded data item.
+type CAT034Record struct {
+	DataSourceID              *CAT034DataSourceID              `json:"data_source_id,omitempty"`              // I034/010
+	MessageType               *int                             `json:"message_type,omitempty"`                // I034/000
+	TimeOfDay                 *float64                         `json:"time_of_day,omitempty"`                 // I034/030
+	SectorNumber              *float64                         `json:"sector_number,omitempty"`               // I034/020
+	AntennaRotationSpeed      *float64                         `json:"antenna_rotation_speed,omitempty"`      // I034/041
+	SystemConfigurationStatus *CAT034SystemConfigurationStatus `json:"system_configuration_status,omitempty"` // I034/050
+	SystemProcessingMode      *CAT034SystemProcessingMode      `json:"system_processing_mode,omitempty"`      // I034/060
+	MessageCountValues        []CAT034MessageCountValues       `json:"message_count_values,omitempty"`        // I034/070
+	GenericPolarWindow        *CAT034GenericPolarWindow        `json:"generic_polar_window,omitempty"`        // I034/100
+	DataFilter                *int                             `json:"data_filter,omitempty"`                 // I034/110
+	DataSourcePosition        *CAT034DataSourcePosition        `json:"data_source_position,omitempty"`        // I034/120
+	CollimationError          *CAT034CollimationError          `json:"collimation_error,omitempty"`           // I034/090
+	ReservedExpansion         interface{}                      `json:"reserved_expansion,omitempty"`          // I034/RE
+	SpecialPurpose            interface{}                      `json:"special_purpose,omitempty"`             // I034/SP
+}
+
+// CAT034DataSourceID holds I034/010, Data Source Identifier
+type CAT034DataSourceID struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT034SystemConfigurationStatusCom holds the Common Part subfield of I034/050
+type CAT034SystemConfigurationStatusCom struct {
+	Nogo   bool `json:"nogo"`
+	Rdpc   bool `json:"rdpc"`
+	Rdpr   bool `json:"rdpr"`
+	OvlRdp bool `json:"ovl_rdp"`
+	OvlXmt bool `json:"ovl_xmt"`
+	Msc    bool `json:"msc"`
+	Tsv    bool `json:"tsv"`
+}
+
+// CAT034SystemConfigurationStatusPSR holds the Specific Status for PSR Sensor subfield of I034/050
+type CAT034SystemConfigurationStatusPSR struct {
+	Ant  int  `json:"ant"`
+	ChAb int  `json:"ch_ab"`
+	Ovl  bool `json:"ovl"`
+	Msc  bool `json:"msc"`
+}
+
+// CAT034SystemConfigurationStatusSSR holds the Specific Status for SSR Sensor subfield of I034/050
+type CAT034SystemConfigurationStatusSSR struct {
+	Ant  int  `json:"ant"`
+	ChAb int  `json:"ch_ab"`
+	Ovl  bool `json:"ovl"`
+	Msc  bool `json:"msc"`
+}
+
+// CAT034SystemConfigurationStatusMds holds the Specific Status for Mode S Sensor subfield of I034/050
+type CAT034SystemConfigurationStatusMds struct {
+	Ant    int  `json:"ant"`
+	ChAb   int  `json:"ch_ab"`
+	OvlSur bool `json:"ovl_sur"`
+	Msc    bool `json:"msc"`
+	Scf    bool `json:"scf"`
+	Dlf    bool `json:"dlf"`
+	OvlScf bool `json:"ovl_scf"`
+	OvlDlf bool `json:"ovl_dlf"`
+}
+
+// CAT034SystemConfigurationStatus holds I034/050, System Configuration and Status
+type CAT034SystemConfigurationStatus struct {
+	Com *CAT034SystemConfigurationStatusCom `json:"com,omitempty"`
+	PSR *CAT034SystemConfigurationStatusPSR `json:"psr,omitempty"`
+	SSR *CAT034SystemConfigurationStatusSSR `json:"ssr,omitempty"`
+	Mds *CAT034SystemConfigurationStatusMds `json:"mds,omitempty"`
+}
+
+// CAT034SystemProcessingModeCom holds the Common Part subfield of I034/060
+type CAT034SystemProcessingModeCom struct {
+	RedRdp int `json:"red_rdp"`
+	RedXmt int `json:"red_xmt"`
+}
+
+// CAT034SystemProcessingModePSR holds the Specific Processing Mode Information for PSR Sensor subfield of I034/060
+type CAT034SystemProcessingModePSR struct {
+	Pol    int `json:"pol"`
+	RedRad int `json:"red_rad"`
+	Stc    int `json:"stc"`
+}
+
+// CAT034SystemProcessingModeMds holds the Specific Processing Mode Information for Mode S Sensor subfield of I034/060
+type CAT034SystemProcessingModeMds struct {
+	RedRad int `json:"red_rad"`
+	Clu    int `json:"clu"`
+}
+
+// CAT034SystemProcessingMode holds I034/060, System Processing Mode
+type CAT034SystemProcessingMode struct {
+	Com *CAT034SystemProcessingModeCom `json:"com,omitempty"`
+	PSR *CAT034SystemProcessingModePSR `json:"psr,omitempty"`
+	SSR *int                           `json:"ssr,omitempty"`
+	Mds *CAT034SystemProcessingModeMds `json:"mds,omitempty"`
+}
+
+// CAT034MessageCountValues holds I034/070, Message Count Values
+type CAT034MessageCountValues struct {
+	Typ   int `json:"typ"`
+	Count int `json:"count"`
+}
+
+// CAT034GenericPolarWindow holds I034/100, Generic Polar Window
+type CAT034GenericPolarWindow struct {
+	RhoStartNM    float64 `json:"rho_start_nm"`
+	RhoEndNM      float64 `json:"rho_end_nm"`
+	ThetaStartDeg float64 `json:"theta_start_deg"`
+	ThetaEndDeg   float64 `json:"theta_end_deg"`
+}
+
+// CAT034DataSourcePosition holds I034/120, 3D-Position Of Data Source
+type CAT034DataSourcePosition struct {
+	HeightM   int     `json:"height_m"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT034CollimationError holds I034/090, Collimation Error
+type CAT034CollimationError struct {
+	RangeErrorNM    float64 `json:"range_error_nm"`
+	AzimuthErrorDeg float64 `json:"azimuth_error_deg"`
+}
+
+// CAT034Records returns the typed records of a CAT 034 data block decoded with
+// edition 1.29
+func (b *Block) CAT034Records() ([]*CAT034Record, error) {
+	return typedRecords[CAT034Record](b, 34, "1.29")
+}
@@ -0,0 +1,210 @@
+package asterix
+
+// CAT048Record is a typed record of CAT 048 edition 1.31. Each field
+// marshals to the same JSON as the decoded data item.
+type CAT048Record struct {
+	DataSourceID                *CAT048DataSourceID                `json:"data_source_id,omitempty"`                // I048/010
+	TimeOfDay                   *float64                           `json:"time_of_day,omitempty"`                   // I048/140
+	TargetReportDescriptor      *CAT048TargetReportDescriptor      `json:"target_report_descriptor,omitempty"`      // I048/020
+	MeasuredPositionPolar       *CAT048MeasuredPositionPolar       `json:"measured_position_polar,omitempty"`       // I048/040
+	Mode3A                      *CAT048Mode3A                      `json:"mode3a,omitempty"`                        // I048/070
+	FlightLevel                 *CAT048FlightLevel                 `json:"flight_level,omitempty"`                  // I048/090
+	RadarPlotCharacteristics    *CAT048RadarPlotCharacteristics    `json:"radar_plot_characteristics,omitempty"`    // I048/130
+	AircraftAddress             *string                            `json:"aircraft_address,omitempty"`              // I048/220
+	AircraftID                  *string                            `json:"aircraft_id,omitempty"`                   // I048/240
+	BDSRegisterData             []CAT048BDSRegisterData            `json:"bds_register_data,omitempty"`             // I048/250
+	TrackNumber                 *int                               `json:"track_number,omitempty"`                  // I048/161
+	CalculatedPositionCartesian *CAT048CalculatedPositionCartesian `json:"calculated_position_cartesian,omitempty"` // I048/042
+	CalculatedTrackVelocity     *CAT048CalculatedTrackVelocity     `json:"calculated_track_velocity,omitempty"`     // I048/200
+	TrackStatus                 *CAT048TrackStatus                 `json:"track_status,omitempty"`                  // I048/170
+	TrackQuality                *CAT048TrackQuality                `json:"track_quality,omitempty"`                 // I048/210
+	WarningErrorConditions      []int                              `json:"warning_error_conditions,omitempty"`      // I048/030
+	Mode3AConfidence            *int                               `json:"mode3a_confidence,omitempty"`             // I048/080
+	ModeC                       *CAT048ModeC                       `json:"mode_c,omitempty"`                        // I048/100
+	Height3D                    *float64                           `json:"height_3d,omitempty"`                     // I048/110
+	RadialDopplerSpeed          *CAT048RadialDopplerSpeed          `json:"radial_doppler_speed,omitempty"`          // I048/120
+	CommsACASCapability         *CAT048CommsACASCapability         `json:"comms_acas_capability,omitempty"`         // I048/230
+	ACASResolutionAdvisory      *CAT048ACASResolutionAdvisory      `json:"acas_resolution_advisory,omitempty"`      // I048/260
+	Mode1                       *CAT048Mode1                       `json:"mode1,omitempty"`                         // I048/055
+	Mode2                       *CAT048Mode2                       `json:"mode2,omitempty"`                         // I048/050
+	Mode1Confidence             *int                               `json:"mode1_confidence,omitempty"`              // I048/065
+	Mode2Confidence             *int                               `json:"mode2_confidence,omitempty"`              // I048/060
+	SpecialPurpose              interface{}                        `json:"special_purpose,omitempty"`               // I048/SP
+	ReservedExpansion           interface{}                        `json:"reserved_expansion,omitempty"`            // I048/RE
+}
+
+// CAT048DataSourceID holds I048/010, Data Source Identifier
+type CAT048DataSourceID struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT048TargetReportDescriptor holds I048/020, Target Report Descriptor
+type CAT048TargetReportDescriptor struct {
+	Typ       int    `json:"typ"`
+	Sim       bool   `json:"sim"`
+	Rdp       int    `json:"rdp"`
+	Spi       bool   `json:"spi"`
+	Rab       bool   `json:"rab"`
+	Tst       *bool  `json:"tst,omitempty"`
+	Err       *bool  `json:"err,omitempty"`
+	Xpp       *bool  `json:"xpp,omitempty"`
+	Me        *bool  `json:"me,omitempty"`
+	Mi        *bool  `json:"mi,omitempty"`
+	FoeFri    *int   `json:"foe_fri,omitempty"`
+	ADSBEp    *bool  `json:"adsb_ep,omitempty"`
+	ADSBVal   *bool  `json:"adsb_val,omitempty"`
+	ScnEp     *bool  `json:"scn_ep,omitempty"`
+	ScnVal    *bool  `json:"scn_val,omitempty"`
+	PaiEp     *bool  `json:"pai_ep,omitempty"`
+	PaiVal    *bool  `json:"pai_val,omitempty"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT048MeasuredPositionPolar holds I048/040, Measured Position in Polar Co-ordinates
+type CAT048MeasuredPositionPolar struct {
+	RhoNM    float64 `json:"rho_nm"`
+	ThetaDeg float64 `json:"theta_deg"`
+}
+
+// CAT048Mode3A holds I048/070, Mode-3/A Code in Octal Representation
+type CAT048Mode3A struct {
+	Validated bool   `json:"validated"`
+	Garbled   bool   `json:"garbled"`
+	Smoothed  bool   `json:"smoothed"`
+	Code      string `json:"code"`
+}
+
+// CAT048FlightLevel holds I048/090, Flight Level in Binary Representation
+type CAT048FlightLevel struct {
+	Validated bool    `json:"validated"`
+	Garbled   bool    `json:"garbled"`
+	Fl        float64 `json:"fl"`
+}
+
+// CAT048RadarPlotCharacteristics holds I048/130, Radar Plot Characteristics
+type CAT048RadarPlotCharacteristics struct {
+	Srl *float64 `json:"srl,omitempty"`
+	Srr *int     `json:"srr,omitempty"`
+	Sam *int     `json:"sam,omitempty"`
+	Prl *float64 `json:"prl,omitempty"`
+	Pam *int     `json:"pam,omitempty"`
+	Rpd *float64 `json:"rpd,omitempty"`
+	Apd *float64 `json:"apd,omitempty"`
+}
+
+// CAT048BDSRegisterData holds I048/250, BDS Register Data
+type CAT048BDSRegisterData struct {
+	MBData string       `json:"mb_data"`
+	Bds1   int          `json:"bds1"`
+	Bds2   int          `json:"bds2"`
+	BDS    *BDSRegister `json:"bds,omitempty"`
+}
+
+// CAT048CalculatedPositionCartesian holds I048/042, Calculated Position in Cartesian Co-ordinates
+type CAT048CalculatedPositionCartesian struct {
+	XNM float64 `json:"x_nm"`
+	YNM float64 `json:"y_nm"`
+}
+
+// CAT048CalculatedTrackVelocity holds I048/200, Calculated Track Velocity in Polar Co-ordinates
+type CAT048CalculatedTrackVelocity struct {
+	GroundspeedNMS float64 `json:"groundspeed_nm_s"`
+	HeadingDeg     float64 `json:"heading_deg"`
+}
+
+// CAT048TrackStatus holds I048/170, Track Status
+type CAT048TrackStatus struct {
+	Cnf       bool   `json:"cnf"`
+	Rad       int    `json:"rad"`
+	Dou       bool   `json:"dou"`
+	Mah       bool   `json:"mah"`
+	CDM       int    `json:"cdm"`
+	Tre       *bool  `json:"tre,omitempty"`
+	Gho       *bool  `json:"gho,omitempty"`
+	Sup       *bool  `json:"sup,omitempty"`
+	Tcc       *bool  `json:"tcc,omitempty"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT048TrackQuality holds I048/210, Track Quality
+type CAT048TrackQuality struct {
+	SigmaXNM  float64 `json:"sigma_x_nm"`
+	SigmaYNM  float64 `json:"sigma_y_nm"`
+	SigmaVNMS float64 `json:"sigma_v_nm_s"`
+	SigmaHDeg float64 `json:"sigma_h_deg"`
+}
+
+// CAT048ModeC holds I048/100, Mode-C Code and Code Confidence Indicator
+type CAT048ModeC struct {
+	Validated  bool `json:"validated"`
+	Garbled    bool `json:"garbled"`
+	CodeGray   int  `json:"code_gray"`
+	Confidence int  `json:"confidence"`
+}
+
+// CAT048RadialDopplerSpeedCal holds the Calculated Doppler Speed subfield of I048/120
+type CAT048RadialDopplerSpeedCal struct {
+	Doubtful bool `json:"doubtful"`
+	SpeedMS  int  `json:"speed_m_s"`
+}
+
+// CAT048RadialDopplerSpeedRds holds the Raw Doppler Speed subfield of I048/120
+type CAT048RadialDopplerSpeedRds struct {
+	DopplerMS    int `json:"doppler_m_s"`
+	AmbiguityMS  int `json:"ambiguity_m_s"`
+	FrequencyMhz int `json:"frequency_mhz"`
+}
+
+// CAT048RadialDopplerSpeed holds I048/120, Radial Doppler Speed
+type CAT048RadialDopplerSpeed struct {
+	Cal *CAT048RadialDopplerSpeedCal  `json:"cal,omitempty"`
+	Rds []CAT048RadialDopplerSpeedRds `json:"rds,omitempty"`
+}
+
+// CAT048CommsACASCapability holds I048/230, Communications/ACAS Capability and Flight Status
+type CAT048CommsACASCapability struct {
+	Com  int  `json:"com"`
+	Stat int  `json:"stat"`
+	Si   bool `json:"si"`
+	Mssc bool `json:"mssc"`
+	ARC  bool `json:"arc"`
+	Aic  bool `json:"aic"`
+	B1a  int  `json:"b1a"`
+	B1b  int  `json:"b1b"`
+}
+
+// CAT048ACASResolutionAdvisory holds I048/260, ACAS Resolution Advisory Report
+type CAT048ACASResolutionAdvisory struct {
+	Typ  int  `json:"typ"`
+	Styp int  `json:"styp"`
+	Ara  int  `json:"ara"`
+	Rac  int  `json:"rac"`
+	Rat  bool `json:"rat"`
+	Mte  bool `json:"mte"`
+	Tti  int  `json:"tti"`
+	Tid  int  `json:"tid"`
+}
+
+// CAT048Mode1 holds I048/055, Mode-1 Code in Octal Representation
+type CAT048Mode1 struct {
+	Validated bool `json:"validated"`
+	Garbled   bool `json:"garbled"`
+	Smoothed  bool `json:"smoothed"`
+	CodeA     int  `json:"code_a"`
+	CodeB     int  `json:"code_b"`
+}
+
+// CAT048Mode2 holds I048/050, Mode-2 Code in Octal Representation
+type CAT048Mode2 struct {
+	Validated bool   `json:"validated"`
+	Garbled   bool   `json:"garbled"`
+	Smoothed  bool   `json:"smoothed"`
+	Code      string `json:"code"`
+}
+
+// CAT048Records returns the typed records of a CAT 048 data block decoded with
+// edition 1.31
+func (b *Block) CAT048Records() ([]*CAT048Record, error) {
+	return typedRecords[CAT048Record](b, 48, "1.31")
+}
@@ -0,0 +1,541 @@
+package asterix
+
+// CAT062Record is a typed record of CAT 062 edition 1.18. Each field
+// marshals to the same JSON as the decoded data item.
+type CAT062Record struct {
+	DataSourceID          *CAT062DataSourceID          `json:"data_source_id,omitempty"`           // I062/010
+	ServiceID             *int                         `json:"service_id,omitempty"`               // I062/015
+	TimeOfTrack           *float64                     `json:"time_of_track,omitempty"`            // I062/070
+	PositionWGS84         *CAT062PositionWGS84         `json:"position_wgs84,omitempty"`           // I062/105
+	PositionCartesian     *CAT062PositionCartesian     `json:"position_cartesian,omitempty"`       // I062/100
+	VelocityCartesian     *CAT062VelocityCartesian     `json:"velocity_cartesian,omitempty"`       // I062/185
+	AccelerationCartesian *CAT062AccelerationCartesian `json:"acceleration_cartesian,omitempty"`   // I062/210
+	Mode3A                *CAT062Mode3A                `json:"mode3a,omitempty"`                   // I062/060
+	TargetIdentification  *CAT062TargetIdentification  `json:"target_identification,omitempty"`    // I062/245
+	AircraftDerivedData   *CAT062AircraftDerivedData   `json:"aircraft_derived_data,omitempty"`    // I062/380
+	TrackNumber           *int                         `json:"track_number,omitempty"`             // I062/040
+	TrackStatus           *CAT062TrackStatus           `json:"track_status,omitempty"`             // I062/080
+	SystemTrackUpdateAges *CAT062SystemTrackUpdateAges `json:"system_track_update_ages,omitempty"` // I062/290
+	ModeOfMovement        *CAT062ModeOfMovement        `json:"mode_of_movement,omitempty"`         // I062/200
+	TrackDataAges         *CAT062TrackDataAges         `json:"track_data_ages,omitempty"`          // I062/295
+	MeasuredFlightLevel   *float64                     `json:"measured_flight_level,omitempty"`    // I062/136
+	GeometricAltitude     *float64                     `json:"geometric_altitude,omitempty"`       // I062/130
+	BarometricAltitude    *CAT062BarometricAltitude    `json:"barometric_altitude,omitempty"`      // I062/135
+	RateOfClimbDescent    *float64                     `json:"rate_of_climb_descent,omitempty"`    // I062/220
+	FlightPlanData        *CAT062FlightPlanData        `json:"flight_plan_data,omitempty"`         // I062/390
+	TargetSizeOrientation *CAT062TargetSizeOrientation `json:"target_size_orientation,omitempty"`  // I062/270
+	VehicleFleetID        *int                         `json:"vehicle_fleet_id,omitempty"`         // I062/300
+	Mode5Data             *CAT062Mode5Data             `json:"mode5_data,omitempty"`               // I062/110
+	Mode2                 *string                      `json:"mode2,omitempty"`                    // I062/120
+	ComposedTrackNumber   []CAT062ComposedTrackNumber  `json:"composed_track_number,omitempty"`    // I062/510
+	EstimatedAccuracies   *CAT062EstimatedAccuracies   `json:"estimated_accuracies,omitempty"`     // I062/500
+	MeasuredInformation   *CAT062MeasuredInformation   `json:"measured_information,omitempty"`     // I062/340
+	ReservedExpansion     interface{}                  `json:"reserved_expansion,omitempty"`       // I062/RE
+	SpecialPurpose        interface{}                  `json:"special_purpose,omitempty"`          // I062/SP
+}
+
+// CAT062DataSourceID holds I062/010, Data Source Identifier
+type CAT062DataSourceID struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT062PositionWGS84 holds I062/105, Calculated Track Position (WGS-84)
+type CAT062PositionWGS84 struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT062PositionCartesian holds I062/100, Calculated Track Position (Cartesian)
+type CAT062PositionCartesian struct {
+	XM float64 `json:"x_m"`
+	YM float64 `json:"y_m"`
+}
+
+// CAT062VelocityCartesian holds I062/185, Calculated Track Velocity (Cartesian)
+type CAT062VelocityCartesian struct {
+	VxMS float64 `json:"vx_m_s"`
+	VyMS float64 `json:"vy_m_s"`
+}
+
+// CAT062AccelerationCartesian holds I062/210, Calculated Acceleration (Cartesian)
+type CAT062AccelerationCartesian struct {
+	AxMS2 float64 `json:"ax_m_s2"`
+	AyMS2 float64 `json:"ay_m_s2"`
+}
+
+// CAT062Mode3A holds I062/060, Track Mode 3/A Code
+type CAT062Mode3A struct {
+	Changed bool   `json:"changed"`
+	Code    string `json:"code"`
+}
+
+// CAT062TargetIdentification holds I062/245, Target Identification
+type CAT062TargetIdentification struct {
+	Sti      int    `json:"sti"`
+	Callsign string `json:"callsign"`
+}
+
+// CAT062AircraftDerivedDataIAS holds the Indicated Airspeed/Mach No subfield of I062/380
+type CAT062AircraftDerivedDataIAS struct {
+	Mach  bool `json:"mach"`
+	Speed int  `json:"speed"`
+}
+
+// CAT062AircraftDerivedDataSal holds the Selected Altitude subfield of I062/380
+type CAT062AircraftDerivedDataSal struct {
+	Sas        bool    `json:"sas"`
+	Source     int     `json:"source"`
+	AltitudeFt float64 `json:"altitude_ft"`
+}
+
+// CAT062AircraftDerivedDataFss holds the Final State Selected Altitude subfield of I062/380
+type CAT062AircraftDerivedDataFss struct {
+	Mv         bool    `json:"mv"`
+	Ah         bool    `json:"ah"`
+	Am         bool    `json:"am"`
+	AltitudeFt float64 `json:"altitude_ft"`
+}
+
+// CAT062AircraftDerivedDataTis holds the Trajectory Intent Status subfield of I062/380
+type CAT062AircraftDerivedDataTis struct {
+	Nav       bool   `json:"nav"`
+	Nvb       bool   `json:"nvb"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT062AircraftDerivedDataTid holds the Trajectory Intent Data subfield of I062/380
+type CAT062AircraftDerivedDataTid struct {
+	Tca        bool    `json:"tca"`
+	Nc         bool    `json:"nc"`
+	TcpNumber  int     `json:"tcp_number"`
+	AltitudeFt float64 `json:"altitude_ft"`
+	Latitude   float64 `json:"latitude"`
+	Longitude  float64 `json:"longitude"`
+	PointType  int     `json:"point_type"`
+	Td         int     `json:"td"`
+	Tra        bool    `json:"tra"`
+	Toa        bool    `json:"toa"`
+	TOVS       int     `json:"tov_s"`
+	TTRNM      float64 `json:"ttr_nm"`
+}
+
+// CAT062AircraftDerivedDataCom holds the Communications/ACAS Capability and Flight Status subfield of I062/380
+type CAT062AircraftDerivedDataCom struct {
+	Com  int  `json:"com"`
+	Stat int  `json:"stat"`
+	Ssc  bool `json:"ssc"`
+	ARC  bool `json:"arc"`
+	Aic  bool `json:"aic"`
+	B1a  int  `json:"b1a"`
+	B1b  int  `json:"b1b"`
+}
+
+// CAT062AircraftDerivedDataSab holds the Status reported by ADS-B subfield of I062/380
+type CAT062AircraftDerivedDataSab struct {
+	Ac   int  `json:"ac"`
+	Mn   int  `json:"mn"`
+	Dc   int  `json:"dc"`
+	Gbs  bool `json:"gbs"`
+	Stat int  `json:"stat"`
+}
+
+// CAT062AircraftDerivedDataAcs holds the ACAS Resolution Advisory Report subfield of I062/380
+type CAT062AircraftDerivedDataAcs struct {
+	Typ  int  `json:"typ"`
+	Styp int  `json:"styp"`
+	Ara  int  `json:"ara"`
+	Rac  int  `json:"rac"`
+	Rat  bool `json:"rat"`
+	Mte  bool `json:"mte"`
+	Tti  int  `json:"tti"`
+	Tid  int  `json:"tid"`
+}
+
+// CAT062AircraftDerivedDataTar holds the Track Angle Rate subfield of I062/380
+type CAT062AircraftDerivedDataTar struct {
+	Ti       int     `json:"ti"`
+	RateDegS float64 `json:"rate_deg_s"`
+}
+
+// CAT062AircraftDerivedDataMet holds the Met Data subfield of I062/380
+type CAT062AircraftDerivedDataMet struct {
+	WsValid          bool    `json:"ws_valid"`
+	WdValid          bool    `json:"wd_valid"`
+	TmpValid         bool    `json:"tmp_valid"`
+	TrbValid         bool    `json:"trb_valid"`
+	WindSpeedKt      int     `json:"wind_speed_kt"`
+	WindDirectionDeg int     `json:"wind_direction_deg"`
+	TemperatureC     float64 `json:"temperature_c"`
+	Turbulence       int     `json:"turbulence"`
+}
+
+// CAT062AircraftDerivedDataPos holds the Position subfield of I062/380
+type CAT062AircraftDerivedDataPos struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT062AircraftDerivedDataMB holds the Mode S MB Data subfield of I062/380
+type CAT062AircraftDerivedDataMB struct {
+	MBData string       `json:"mb_data"`
+	Bds1   int          `json:"bds1"`
+	Bds2   int          `json:"bds2"`
+	BDS    *BDSRegister `json:"bds,omitempty"`
+}
+
+// CAT062AircraftDerivedData holds I062/380, Aircraft Derived Data
+type CAT062AircraftDerivedData struct {
+	Adr *string                        `json:"adr,omitempty"`
+	ID  *string                        `json:"id,omitempty"`
+	Mhg *float64                       `json:"mhg,omitempty"`
+	IAS *CAT062AircraftDerivedDataIAS  `json:"ias,omitempty"`
+	TAS *int                           `json:"tas,omitempty"`
+	Sal *CAT062AircraftDerivedDataSal  `json:"sal,omitempty"`
+	Fss *CAT062AircraftDerivedDataFss  `json:"fss,omitempty"`
+	Tis *CAT062AircraftDerivedDataTis  `json:"tis,omitempty"`
+	Tid []CAT062AircraftDerivedDataTid `json:"tid,omitempty"`
+	Com *CAT062AircraftDerivedDataCom  `json:"com,omitempty"`
+	Sab *CAT062AircraftDerivedDataSab  `json:"sab,omitempty"`
+	Acs *CAT062AircraftDerivedDataAcs  `json:"acs,omitempty"`
+	Bvr *float64                       `json:"bvr,omitempty"`
+	Gvr *float64                       `json:"gvr,omitempty"`
+	Ran *float64                       `json:"ran,omitempty"`
+	Tar *CAT062AircraftDerivedDataTar  `json:"tar,omitempty"`
+	Tan *float64                       `json:"tan,omitempty"`
+	Gsp *float64                       `json:"gsp,omitempty"`
+	Vun *int                           `json:"vun,omitempty"`
+	Met *CAT062AircraftDerivedDataMet  `json:"met,omitempty"`
+	Emc *int                           `json:"emc,omitempty"`
+	Pos *CAT062AircraftDerivedDataPos  `json:"pos,omitempty"`
+	Gal *float64                       `json:"gal,omitempty"`
+	Pun *int                           `json:"pun,omitempty"`
+	MB  []CAT062AircraftDerivedDataMB  `json:"mb,omitempty"`
+	Iar *int                           `json:"iar,omitempty"`
+	Mac *float64                       `json:"mac,omitempty"`
+	Bps *float64                       `json:"bps,omitempty"`
+}
+
+// CAT062TrackStatus holds I062/080, Track Status
+type CAT062TrackStatus struct {
+	Mon       bool   `json:"mon"`
+	Spi       bool   `json:"spi"`
+	Mrh       bool   `json:"mrh"`
+	Src       int    `json:"src"`
+	Cnf       bool   `json:"cnf"`
+	Sim       *bool  `json:"sim,omitempty"`
+	Tse       *bool  `json:"tse,omitempty"`
+	Tsb       *bool  `json:"tsb,omitempty"`
+	Fpc       *bool  `json:"fpc,omitempty"`
+	Aff       *bool  `json:"aff,omitempty"`
+	Stp       *bool  `json:"stp,omitempty"`
+	Kos       *bool  `json:"kos,omitempty"`
+	Ama       *bool  `json:"ama,omitempty"`
+	Md4       *int   `json:"md4,omitempty"`
+	Me        *bool  `json:"me,omitempty"`
+	Mi        *bool  `json:"mi,omitempty"`
+	Md5       *int   `json:"md5,omitempty"`
+	Cst       *bool  `json:"cst,omitempty"`
+	PSR       *bool  `json:"psr,omitempty"`
+	SSR       *bool  `json:"ssr,omitempty"`
+	Mds       *bool  `json:"mds,omitempty"`
+	Ads       *bool  `json:"ads,omitempty"`
+	Suc       *bool  `json:"suc,omitempty"`
+	Aac       *bool  `json:"aac,omitempty"`
+	Sds       *int   `json:"sds,omitempty"`
+	Ems       *int   `json:"ems,omitempty"`
+	Pft       *bool  `json:"pft,omitempty"`
+	Fplt      *bool  `json:"fplt,omitempty"`
+	Dupt      *bool  `json:"dupt,omitempty"`
+	Dupf      *bool  `json:"dupf,omitempty"`
+	Dupm      *bool  `json:"dupm,omitempty"`
+	Sfc       *bool  `json:"sfc,omitempty"`
+	Idd       *bool  `json:"idd,omitempty"`
+	Iec       *bool  `json:"iec,omitempty"`
+	Extension string `json:"extension,omitempty"`
+}
+
+// CAT062SystemTrackUpdateAges holds I062/290, System Track Update Ages
+type CAT062SystemTrackUpdateAges struct {
+	Trk *float64 `json:"trk,omitempty"`
+	PSR *float64 `json:"psr,omitempty"`
+	SSR *float64 `json:"ssr,omitempty"`
+	Mds *float64 `json:"mds,omitempty"`
+	Ads *float64 `json:"ads,omitempty"`
+	Es  *float64 `json:"es,omitempty"`
+	Vdl *float64 `json:"vdl,omitempty"`
+	Uat *float64 `json:"uat,omitempty"`
+	Lop *float64 `json:"lop,omitempty"`
+	Mlt *float64 `json:"mlt,omitempty"`
+}
+
+// CAT062ModeOfMovement holds I062/200, Mode of Movement
+type CAT062ModeOfMovement struct {
+	Trans int  `json:"trans"`
+	Long  int  `json:"long"`
+	Vert  int  `json:"vert"`
+	Adf   bool `json:"adf"`
+}
+
+// CAT062TrackDataAges holds I062/295, Track Data Ages
+type CAT062TrackDataAges struct {
+	Mfl *float64 `json:"mfl,omitempty"`
+	Md1 *float64 `json:"md1,omitempty"`
+	Md2 *float64 `json:"md2,omitempty"`
+	Mda *float64 `json:"mda,omitempty"`
+	Md4 *float64 `json:"md4,omitempty"`
+	Md5 *float64 `json:"md5,omitempty"`
+	Mhg *float64 `json:"mhg,omitempty"`
+	IAS *float64 `json:"ias,omitempty"`
+	TAS *float64 `json:"tas,omitempty"`
+	Sal *float64 `json:"sal,omitempty"`
+	Fss *float64 `json:"fss,omitempty"`
+	Tid *float64 `json:"tid,omitempty"`
+	Com *float64 `json:"com,omitempty"`
+	Sab *float64 `json:"sab,omitempty"`
+	Acs *float64 `json:"acs,omitempty"`
+	Bvr *float64 `json:"bvr,omitempty"`
+	Gvr *float64 `json:"gvr,omitempty"`
+	Ran *float64 `json:"ran,omitempty"`
+	Tar *float64 `json:"tar,omitempty"`
+	Tan *float64 `json:"tan,omitempty"`
+	Gsp *float64 `json:"gsp,omitempty"`
+	Vun *float64 `json:"vun,omitempty"`
+	Met *float64 `json:"met,omitempty"`
+	Emc *float64 `json:"emc,omitempty"`
+	Pos *float64 `json:"pos,omitempty"`
+	Gal *float64 `json:"gal,omitempty"`
+	Pun *float64 `json:"pun,omitempty"`
+	MB  *float64 `json:"mb,omitempty"`
+	Iar *float64 `json:"iar,omitempty"`
+	Mac *float64 `json:"mac,omitempty"`
+	Bps *float64 `json:"bps,omitempty"`
+}
+
+// CAT062BarometricAltitude holds I062/135, Calculated Track Barometric Altitude
+type CAT062BarometricAltitude struct {
+	QnhCorrected bool    `json:"qnh_corrected"`
+	Fl           float64 `json:"fl"`
+}
+
+// CAT062FlightPlanDataTag holds the FPPS Identification Tag subfield of I062/390
+type CAT062FlightPlanDataTag struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT062FlightPlanDataIfi holds the IFPS_FLIGHT_ID subfield of I062/390
+type CAT062FlightPlanDataIfi struct {
+	Typ int `json:"typ"`
+	Nbr int `json:"nbr"`
+}
+
+// CAT062FlightPlanDataFct holds the Flight Category subfield of I062/390
+type CAT062FlightPlanDataFct struct {
+	GatOat int  `json:"gat_oat"`
+	Fr1Fr2 int  `json:"fr1_fr2"`
+	RVSM   int  `json:"rvsm"`
+	Hpr    bool `json:"hpr"`
+}
+
+// CAT062FlightPlanDataCtl holds the Current Control Position subfield of I062/390
+type CAT062FlightPlanDataCtl struct {
+	Centre   int `json:"centre"`
+	Position int `json:"position"`
+}
+
+// CAT062FlightPlanDataTod holds the Time of Departure / Arrival subfield of I062/390
+type CAT062FlightPlanDataTod struct {
+	Typ    int  `json:"typ"`
+	Day    int  `json:"day"`
+	Hour   int  `json:"hour"`
+	Minute int  `json:"minute"`
+	Avs    bool `json:"avs"`
+	Second int  `json:"second"`
+}
+
+// CAT062FlightPlanDataSts holds the Stand Status subfield of I062/390
+type CAT062FlightPlanDataSts struct {
+	Emp int `json:"emp"`
+	Avl int `json:"avl"`
+}
+
+// CAT062FlightPlanDataPem holds the Pre-Emergency Mode 3/A Code subfield of I062/390
+type CAT062FlightPlanDataPem struct {
+	Va   bool   `json:"va"`
+	Code string `json:"code"`
+}
+
+// CAT062FlightPlanData holds I062/390, Flight Plan Related Data
+type CAT062FlightPlanData struct {
+	Tag *CAT062FlightPlanDataTag  `json:"tag,omitempty"`
+	Csn *string                   `json:"csn,omitempty"`
+	Ifi *CAT062FlightPlanDataIfi  `json:"ifi,omitempty"`
+	Fct *CAT062FlightPlanDataFct  `json:"fct,omitempty"`
+	Tac *string                   `json:"tac,omitempty"`
+	Wtc *string                   `json:"wtc,omitempty"`
+	Dep *string                   `json:"dep,omitempty"`
+	Dst *string                   `json:"dst,omitempty"`
+	Rds *string                   `json:"rds,omitempty"`
+	Cfl *float64                  `json:"cfl,omitempty"`
+	Ctl *CAT062FlightPlanDataCtl  `json:"ctl,omitempty"`
+	Tod []CAT062FlightPlanDataTod `json:"tod,omitempty"`
+	Ast *string                   `json:"ast,omitempty"`
+	Sts *CAT062FlightPlanDataSts  `json:"sts,omitempty"`
+	Std *string                   `json:"std,omitempty"`
+	Sta *string                   `json:"sta,omitempty"`
+	Pem *CAT062FlightPlanDataPem  `json:"pem,omitempty"`
+	Pec *string                   `json:"pec,omitempty"`
+}
+
+// CAT062TargetSizeOrientation holds I062/270, Target Size & Orientation
+type CAT062TargetSizeOrientation struct {
+	LengthM        int      `json:"length_m"`
+	OrientationDeg *float64 `json:"orientation_deg,omitempty"`
+	WidthM         *int     `json:"width_m,omitempty"`
+	Extension      string   `json:"extension,omitempty"`
+}
+
+// CAT062Mode5DataSum holds the Mode 5 Summary subfield of I062/110
+type CAT062Mode5DataSum struct {
+	M5 bool `json:"m5"`
+	ID bool `json:"id"`
+	Da bool `json:"da"`
+	M1 bool `json:"m1"`
+	M2 bool `json:"m2"`
+	M3 bool `json:"m3"`
+	Mc bool `json:"mc"`
+	X  bool `json:"x"`
+}
+
+// CAT062Mode5DataPmn holds the Mode 5 PIN / National Origin / Mission Code subfield of I062/110
+type CAT062Mode5DataPmn struct {
+	Pin int `json:"pin"`
+	Nat int `json:"nat"`
+	Mis int `json:"mis"`
+}
+
+// CAT062Mode5DataPos holds the Mode 5 Reported Position subfield of I062/110
+type CAT062Mode5DataPos struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT062Mode5DataGa holds the Mode 5 GNSS-derived Altitude subfield of I062/110
+type CAT062Mode5DataGa struct {
+	Res        bool    `json:"res"`
+	AltitudeFt float64 `json:"altitude_ft"`
+}
+
+// CAT062Mode5DataXp holds the X Pulse Presence subfield of I062/110
+type CAT062Mode5DataXp struct {
+	X5 bool `json:"x5"`
+	Xc bool `json:"xc"`
+	X3 bool `json:"x3"`
+	X2 bool `json:"x2"`
+	X1 bool `json:"x1"`
+}
+
+// CAT062Mode5Data holds I062/110, Mode 5 Data reports & Extended Mode 1 Code
+type CAT062Mode5Data struct {
+	Sum *CAT062Mode5DataSum `json:"sum,omitempty"`
+	Pmn *CAT062Mode5DataPmn `json:"pmn,omitempty"`
+	Pos *CAT062Mode5DataPos `json:"pos,omitempty"`
+	Ga  *CAT062Mode5DataGa  `json:"ga,omitempty"`
+	Em1 *string             `json:"em1,omitempty"`
+	Tos *float64            `json:"tos,omitempty"`
+	Xp  *CAT062Mode5DataXp  `json:"xp,omitempty"`
+}
+
+// CAT062ComposedTrackNumber holds I062/510, Composed Track Number
+type CAT062ComposedTrackNumber struct {
+	SystemUnitID      int `json:"system_unit_id"`
+	SystemTrackNumber int `json:"system_track_number"`
+}
+
+// CAT062EstimatedAccuraciesApc holds the Estimated Accuracy Of Track Position (Cartesian) subfield of I062/500
+type CAT062EstimatedAccuraciesApc struct {
+	XM float64 `json:"x_m"`
+	YM float64 `json:"y_m"`
+}
+
+// CAT062EstimatedAccuraciesApw holds the Estimated Accuracy Of Track Position (WGS-84) subfield of I062/500
+type CAT062EstimatedAccuraciesApw struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// CAT062EstimatedAccuraciesAtv holds the Estimated Accuracy Of Track Velocity (Cartesian) subfield of I062/500
+type CAT062EstimatedAccuraciesAtv struct {
+	XMS float64 `json:"x_m_s"`
+	YMS float64 `json:"y_m_s"`
+}
+
+// CAT062EstimatedAccuraciesAa holds the Estimated Accuracy Of Acceleration (Cartesian) subfield of I062/500
+type CAT062EstimatedAccuraciesAa struct {
+	XMS2 float64 `json:"x_m_s2"`
+	YMS2 float64 `json:"y_m_s2"`
+}
+
+// CAT062EstimatedAccuracies holds I062/500, Estimated Accuracies
+type CAT062EstimatedAccuracies struct {
+	Apc *CAT062EstimatedAccuraciesApc `json:"apc,omitempty"`
+	Cov *float64                      `json:"cov,omitempty"`
+	Apw *CAT062EstimatedAccuraciesApw `json:"apw,omitempty"`
+	Aga *float64                      `json:"aga,omitempty"`
+	Aba *float64                      `json:"aba,omitempty"`
+	Atv *CAT062EstimatedAccuraciesAtv `json:"atv,omitempty"`
+	Aa  *CAT062EstimatedAccuraciesAa  `json:"aa,omitempty"`
+	ARC *float64                      `json:"arc,omitempty"`
+}
+
+// CAT062MeasuredInformationSid holds the Sensor Identification subfield of I062/340
+type CAT062MeasuredInformationSid struct {
+	SAC int `json:"sac"`
+	SIC int `json:"sic"`
+}
+
+// CAT062MeasuredInformationPos holds the Measured Position subfield of I062/340
+type CAT062MeasuredInformationPos struct {
+	RhoNM    float64 `json:"rho_nm"`
+	ThetaDeg float64 `json:"theta_deg"`
+}
+
+// CAT062MeasuredInformationMdc holds the Last Measured Mode C Code subfield of I062/340
+type CAT062MeasuredInformationMdc struct {
+	Validated bool    `json:"validated"`
+	Garbled   bool    `json:"garbled"`
+	Fl        float64 `json:"fl"`
+}
+
+// CAT062MeasuredInformationMda holds the Last Measured Mode 3/A Code subfield of I062/340
+type CAT062MeasuredInformationMda struct {
+	Validated bool   `json:"validated"`
+	Garbled   bool   `json:"garbled"`
+	Smoothed  bool   `json:"smoothed"`
+	Code      string `json:"code"`
+}
+
+// CAT062MeasuredInformationTyp holds the Report Type subfield of I062/340
+type CAT062MeasuredInformationTyp struct {
+	Typ int  `json:"typ"`
+	Sim bool `json:"sim"`
+	Rab bool `json:"rab"`
+	Tst bool `json:"tst"`
+}
+
+// CAT062MeasuredInformation holds I062/340, Measured Information
+type CAT062MeasuredInformation struct {
+	Sid *CAT062MeasuredInformationSid `json:"sid,omitempty"`
+	Pos *CAT062MeasuredInformationPos `json:"pos,omitempty"`
+	Hei *float64                      `json:"hei,omitempty"`
+	Mdc *CAT062MeasuredInformationMdc `json:"mdc,omitempty"`
+	Mda *CAT062MeasuredInformationMda `json:"mda,omitempty"`
+	Typ *CAT062MeasuredInformationTyp `json:"typ,omitempty"`
+}
+
+// CAT062Records returns the typed records of a CAT 062 data block decoded with
+// edition 1.18
+func (b *Block) CAT062Records() ([]*CAT062Record, error) {
+	return typedRecords[CAT062Record](b, 62, "1.18")
+}
@@ -0,0 +1,630 @@
+package asterix
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Encode encodes the data blocks of a message in the form produced by
+// Decode. Each block uses the edition it names, or the default edition of
+// its category. Items are looked up by name, so a message unmarshalled from
+// a JSON log entry encodes back to the original octets.
+func Encode(msg *Message) ([]byte, error) {
+	var payload []byte
+	for i, block := range msg.Blocks {
+		data, err := encodeBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", i, err)
+		}
+		payload = append(payload, data...)
+	}
+
+	if msg.Trailing != "" {
+		trailing, err := base64.StdEncoding.DecodeString(msg.Trailing)
+		if err != nil {
+			return nil, fmt.Errorf("trailing: %w", err)
+		}
+		payload = append(payload, trailing...)
+	}
+
+	return payload, nil
+}
+
+// EncodeJSON encodes a message given as JSON, such as a logged message
+func EncodeJSON(data []byte) ([]byte, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, fmt.Errorf("failed to parse message: %w", err)
+	}
+	return Encode(&msg)
+}
+
+// encodeBlock encodes one data block including its CAT/LEN header
+func encodeBlock(block *Block) ([]byte, error) {
+	if block.Category < 1 || block.Category > 255 {
+		return nil, fmt.Errorf("invalid category %d", block.Category)
+	}
+	data := []byte{byte(block.Category), 0, 0}
+
+	if block.Raw != "" {
+		// Unsupported blocks keep their body as decoded
+		body, err := base64.StdEncoding.DecodeString(block.Raw)
+		if err != nil {
+			return nil, fmt.Errorf("raw: %w", err)
+		}
+		data = append(data, body...)
+	} else {
+		spec := LookupSpec(block.Category, block.Edition)
+		if spec == nil {
+			return nil, fmt.Errorf("CAT %03d edition %q is not loaded", block.Category, block.Edition)
+		}
+		for i, record := range block.Records {
+			encoded, err := encodeRecord(record, spec)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: %w", i, err)
+			}
+			data = append(data, encoded...)
+		}
+	}
+
+	if len(data) > math.MaxUint16 {
+		return nil, fmt.Errorf("block of %d octets exceeds the LEN field", len(data))
+	}
+	binary.BigEndian.PutUint16(data[1:3], uint16(len(data)))
+	return data, nil
+}
+
+// encodeRecord encodes the FSPEC and the named items of a record in FRN order
+func encodeRecord(record *Record, spec *Spec) ([]byte, error) {
+	uap, err := spec.recordUAP(record)
+	if err != nil {
+		return nil, err
+	}
+
+	items := record.ItemValues()
+	var fspec, body []byte
+	used := 0
+	for frn := 1; frn <= len(uap); frn++ {
+		item := spec.lookup(uap, frn)
+		if item == nil {
+			continue
+		}
+		value, ok := items[item.Name]
+		if !ok {
+			continue
+		}
+
+		data, err := encodeDataItem(value, item)
+		if err != nil {
+			return nil, fmt.Errorf("I%03d/%s: %w", spec.Category, item.ID, err)
+		}
+		body = append(body, data...)
+		used++
+
+		// Each FSPEC octet holds 7 FRNs and an FX bit
+		for len(fspec) < (frn+6)/7 {
+			if len(fspec) > 0 {
+				fspec[len(fspec)-1] |= 0x01
+			}
+			fspec = append(fspec, 0)
+		}
+		fspec[(frn-1)/7] |= 0x80 >> uint((frn-1)%7)
+	}
+
+	if used != len(items) {
+		return nil, fmt.Errorf("unknown items %s in CAT %03d edition %s", unknownNames(items, spec, uap), spec.Category, spec.Edition)
+	}
+	if len(fspec) == 0 {
+		return nil, fmt.Errorf("record has no items")
+	}
+
+	return append(fspec, body...), nil
+}
+
+// recordUAP returns the UAP named by a record, or chosen by its selector
+// item when the record does not name one
+func (s *Spec) recordUAP(record *Record) ([]string, error) {
+	if s.Selector == nil {
+		return s.UAP, nil
+	}
+
+	switch {
+	case record.UAP == s.Selector.Default:
+		return s.UAP, nil
+	case record.UAP != "":
+		uap, ok := s.UAPs[record.UAP]
+		if !ok {
+			return nil, fmt.Errorf("undefined UAP %q", record.UAP)
+		}
+		return uap, nil
+	}
+
+	_, uap := s.selectUAP(record.ItemValues()[s.item(s.Selector.frn).Name])
+	return uap, nil
+}
+
+// unknownNames lists the item names of a record that are not in a UAP
+func unknownNames(items map[string]interface{}, spec *Spec, uap []string) string {
+	known := make(map[string]bool)
+	for _, id := range uap {
+		if item := spec.Items[id]; item != nil {
+			known[item.Name] = true
+		}
+	}
+
+	var names []string
+	for name := range items {
+		if !known[name] {
+			names = append(names, strconv.Quote(name))
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// encodeDataItem encodes a decoded item value according to its definition
+func encodeDataItem(value interface{}, item *Item) ([]byte, error) {
+	switch item.Format {
+	case FormatFixed:
+		return encodeFields(item.Fields, item.Length, value)
+
+	case FormatExtended:
+		return encodeExtendedItem(value, item)
+
+	case FormatRepetitive:
+		if item.Bulk {
+			bulk, ok := value.(*Bulk)
+			if !ok || bulk.data == nil {
+				return nil, fmt.Errorf("bulk item octets are only kept in the side file")
+			}
+			return append([]byte{byte(bulk.Count)}, bulk.data...), nil
+		}
+
+		if s, ok := value.(string); ok && item.Length == 1 && len(item.Fields) == 1 && item.Fields[0].Type == FieldASCII {
+			if len(s) > math.MaxUint8 {
+				return nil, fmt.Errorf("%d characters exceed the REP field", len(s))
+			}
+			return append([]byte{byte(len(s))}, s...), nil
+		}
+
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("want a list, got %T", value)
+		}
+		if len(values) > math.MaxUint8 {
+			return nil, fmt.Errorf("%d elements exceed the REP field", len(values))
+		}
+		data := []byte{byte(len(values))}
+		for i, element := range values {
+			// The decoded BDS register is derived from the MB data
+			if fields, ok := element.(map[string]interface{}); ok && item.ModeSMB {
+				if _, ok := fields[bdsKey]; ok {
+					element = withoutKey(fields, bdsKey)
+				}
+			}
+			encoded, err := encodeFields(item.Fields, item.Length, element)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			data = append(data, encoded...)
+		}
+		return data, nil
+
+	case FormatExplicit:
+		content, err := encodeExplicitContent(value, item)
+		if err != nil {
+			return nil, err
+		}
+		if len(content) >= math.MaxUint8 {
+			return nil, fmt.Errorf("%d octets exceed the length indicator", len(content))
+		}
+		return append([]byte{byte(len(content) + 1)}, content...), nil
+
+	case FormatCompound:
+		return encodeCompoundItem(value, item)
+	}
+
+	return nil, fmt.Errorf("unknown format %q", item.Format)
+}
+
+// encodeExplicitContent encodes the octets following the length indicator.
+// Content that the decoder could not interpret is kept as base64.
+func encodeExplicitContent(value interface{}, item *Item) ([]byte, error) {
+	if item.Content != nil {
+		content, err := encodeDataItem(value, item.Content)
+		if err == nil {
+			return content, nil
+		}
+		if _, ok := value.(string); !ok {
+			return nil, err
+		}
+	}
+	return decodeBase64(value)
+}
+
+// encodeExtendedItem encodes an item made of FX-chained parts. Parts after
+// the last one holding a named value are omitted.
+func encodeExtendedItem(value interface{}, item *Item) ([]byte, error) {
+	if len(item.Parts) == 0 {
+		return decodeBase64(value)
+	}
+
+	var chunks [][]byte
+	if item.Repeat {
+		values, ok := value.([]interface{})
+		if !ok || len(values) == 0 {
+			return nil, fmt.Errorf("want a non-empty list, got %v", value)
+		}
+		for i, element := range values {
+			layout := item.Parts[len(item.Parts)-1]
+			if i < len(item.Parts) {
+				layout = item.Parts[i]
+			}
+			chunk, err := encodePart(layout, element)
+			if err != nil {
+				return nil, fmt.Errorf("part %d: %w", i+1, err)
+			}
+			chunks = append(chunks, chunk)
+		}
+	} else {
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("want a map, got %T", value)
+		}
+
+		// Parts up to the last one holding a value are present
+		count := 1
+		names := map[string]bool{"extension": true}
+		for i, layout := range item.Parts {
+			for _, f := range layout.Fields {
+				if _, ok := values[f.Name]; ok && f.Type != FieldSpare {
+					count = i + 1
+				}
+				names[f.Name] = true
+			}
+		}
+		if err := checkNames(values, names); err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < count; i++ {
+			chunk, err := encodePart(item.Parts[i], values)
+			if err != nil {
+				return nil, fmt.Errorf("part %d: %w", i+1, err)
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if ext, ok := values["extension"]; ok {
+			if count < len(item.Parts) {
+				return nil, fmt.Errorf("extension needs every defined part")
+			}
+			octets, err := decodeBase64(ext)
+			if err != nil {
+				return nil, fmt.Errorf("extension: %w", err)
+			}
+			for _, b := range octets {
+				chunks = append(chunks, []byte{b})
+			}
+		}
+	}
+
+	var data []byte
+	for i, chunk := range chunks {
+		if i < len(chunks)-1 {
+			chunk[len(chunk)-1] |= 0x01
+		} else {
+			chunk[len(chunk)-1] &^= 0x01
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
+// withoutKey returns a copy of values without key
+func withoutKey(values map[string]interface{}, key string) map[string]interface{} {
+	copied := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if k != key {
+			copied[k] = v
+		}
+	}
+	return copied
+}
+
+// encodePart encodes the fields of one extended part, leaving the FX bit clear
+func encodePart(layout *Part, value interface{}) ([]byte, error) {
+	data := make([]byte, layout.Length)
+	if values, ok := value.(map[string]interface{}); ok {
+		return data, encodeFieldsFrom(values, layout.Fields, data)
+	}
+	// Repeated parts with a single named field may be given as that value
+	return data, encodeFieldsFrom(map[string]interface{}{namedField(layout.Fields).Name: value}, layout.Fields, data)
+}
+
+// encodeCompoundItem encodes the primary subfield bitmap and the subfields
+// present in the value
+func encodeCompoundItem(value interface{}, item *Item) ([]byte, error) {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("want a map, got %T", value)
+	}
+
+	var primary, body []byte
+	used := 0
+	for index, sub := range item.Subfields {
+		if sub == nil {
+			continue
+		}
+		subValue, ok := values[sub.Name]
+		if !ok {
+			continue
+		}
+
+		data, err := encodeDataItem(subValue, sub)
+		if err != nil {
+			return nil, fmt.Errorf("subfield %s: %w", sub.Name, err)
+		}
+		body = append(body, data...)
+		used++
+
+		for len(primary) <= index/7 {
+			if len(primary) > 0 {
+				primary[len(primary)-1] |= 0x01
+			}
+			primary = append(primary, 0)
+		}
+		primary[index/7] |= 0x80 >> uint(index%7)
+	}
+
+	if used != len(values) {
+		names := make(map[string]bool)
+		for _, sub := range item.Subfields {
+			if sub != nil {
+				names[sub.Name] = true
+			}
+		}
+		return nil, checkNames(values, names)
+	}
+	if len(primary) == 0 {
+		primary = []byte{0}
+	}
+
+	return append(primary, body...), nil
+}
+
+// encodeFields encodes a fixed layout of length octets from the value
+// decodeFields produces for it. Named fields missing from a map are zero.
+func encodeFields(fields []*Field, length int, value interface{}) ([]byte, error) {
+	if len(fields) == 0 {
+		data, err := decodeBase64(value)
+		if err == nil && len(data) != length {
+			err = fmt.Errorf("%d octets, want %d", len(data), length)
+		}
+		return data, err
+	}
+
+	data := make([]byte, length)
+	if isSingleField(fields) {
+		return data, encodeFieldsFrom(map[string]interface{}{namedField(fields).Name: value}, fields, data)
+	}
+
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("want a map, got %T", value)
+	}
+	names := make(map[string]bool)
+	for _, f := range fields {
+		names[f.Name] = f.Type != FieldSpare
+	}
+	if err := checkNames(values, names); err != nil {
+		return nil, err
+	}
+	return data, encodeFieldsFrom(values, fields, data)
+}
+
+// encodeFieldsFrom encodes each named field of a layout from values
+func encodeFieldsFrom(values map[string]interface{}, fields []*Field, data []byte) error {
+	bit := 0
+	for _, f := range fields {
+		if value, ok := values[f.Name]; ok && f.Type != FieldSpare {
+			if err := f.encode(data, bit, value); err != nil {
+				return fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+		bit += f.Bits
+	}
+	return nil
+}
+
+// isSingleField reports whether a layout decodes to a single scalar value
+func isSingleField(fields []*Field) bool {
+	count := 0
+	for _, f := range fields {
+		if f.Type != FieldSpare {
+			count++
+		}
+	}
+	return count == 1
+}
+
+// namedField returns the last named field of a layout
+func namedField(fields []*Field) *Field {
+	named := &Field{}
+	for _, f := range fields {
+		if f.Type != FieldSpare {
+			named = f
+		}
+	}
+	return named
+}
+
+// checkNames rejects map keys that are not known names, which would
+// otherwise be silently dropped
+func checkNames(values map[string]interface{}, names map[string]bool) error {
+	var unknown []string
+	for name := range values {
+		if !names[name] {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown fields %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// encode writes the field value starting at the given bit offset
+func (f *Field) encode(data []byte, bit int, value interface{}) error {
+	switch f.Type {
+	case FieldBool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("want a bool, got %T", value)
+		}
+		if b != f.Invert {
+			writeBits(data, bit, 1, 1)
+		}
+		return nil
+
+	case FieldOctal, FieldHex:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %T", value)
+		}
+		base := 16
+		if f.Type == FieldOctal {
+			base = 8
+		}
+		n, err := strconv.ParseUint(s, base, f.Bits)
+		if err != nil {
+			return err
+		}
+		writeBits(data, bit, f.Bits, n)
+		return nil
+
+	case FieldICAO6:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %T", value)
+		}
+		return encodeICAO6(data, bit, f.Bits/6, s)
+
+	case FieldASCII:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("want a string, got %T", value)
+		}
+		if len(s) > f.Bits/8 {
+			return fmt.Errorf("%q is longer than %d characters", s, f.Bits/8)
+		}
+		s += strings.Repeat(" ", f.Bits/8-len(s))
+		for i := 0; i < len(s); i++ {
+			writeBits(data, bit+i*8, 8, uint64(s[i]))
+		}
+		return nil
+
+	case FieldRaw:
+		octets, err := decodeBase64(value)
+		if err != nil {
+			return err
+		}
+		if len(octets) != f.Bits/8 {
+			return fmt.Errorf("%d octets, want %d", len(octets), f.Bits/8)
+		}
+		for i, b := range octets {
+			writeBits(data, bit+i*8, 8, uint64(b))
+		}
+		return nil
+	}
+
+	number, ok := toFloat(value)
+	if !ok {
+		return fmt.Errorf("want a number, got %T", value)
+	}
+	if f.scale != 0 {
+		number /= f.scale
+	}
+	number = math.Round(number)
+
+	min, max := 0.0, math.Ldexp(1, f.Bits)-1
+	if f.Type == FieldInt {
+		min, max = -math.Ldexp(1, f.Bits-1), math.Ldexp(1, f.Bits-1)-1
+	}
+	if number < min || number > max {
+		return fmt.Errorf("%v is out of range", value)
+	}
+
+	writeBits(data, bit, f.Bits, uint64(int64(number)))
+	return nil
+}
+
+// toFloat converts a decoded or JSON number to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// decodeBase64 decodes a value holding base64-encoded octets
+func decodeBase64(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("want base64 octets, got %T", value)
+	}
+	return base64.StdEncoding.DecodeString(s)
+}
+
+// writeBits writes the low n bits of value starting at the given bit offset,
+// most significant bit first
+func writeBits(data []byte, bit, n int, value uint64) {
+	for i := 0; i < n; i++ {
+		pos := bit + i
+		if value>>(uint(n-1-i))&0x01 != 0 {
+			data[pos/8] |= 0x80 >> uint(pos%8)
+		} else {
+			data[pos/8] &^= 0x80 >> uint(pos%8)
+		}
+	}
+}
+
+// encodeICAO6 writes a string as count ICAO 6-bit characters, padded with
+// spaces
+func encodeICAO6(data []byte, bit, count int, s string) error {
+	if len(s) > count {
+		return fmt.Errorf("%q is longer than %d characters", s, count)
+	}
+	s += strings.Repeat(" ", count-len(s))
+
+	for i := 0; i < count; i++ {
+		var code uint64
+		switch c := s[i]; {
+		case c >= 'A' && c <= 'Z':
+			code = uint64(c-'A') + 1
+		case c == ' ':
+			code = 32
+		case c >= '0' && c <= '9':
+			code = uint64(c-'0') + 48
+		default:
+			return fmt.Errorf("%q cannot be encoded in ICAO 6-bit characters", c)
+		}
+		writeBits(data, bit+i*6, 6, code)
+	}
+	return nil
+}
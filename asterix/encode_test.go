@@ -0,0 +1,141 @@
+package asterix
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// assertRoundTrip checks that a payload survives decoding, a trip through
+// JSON as written to the log, and encoding
+func assertRoundTrip(t *testing.T, payload []byte) {
+	t.Helper()
+
+	data, err := json.Marshal(decodeMessage(payload, nil))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	encoded, err := EncodeJSON(data)
+	if err != nil {
+		t.Fatalf("EncodeJSON() error = %v\n%s", err, data)
+	}
+	if !bytes.Equal(encoded, payload) {
+		t.Errorf("EncodeJSON() = %x\nwant %x", encoded, payload)
+	}
+}
+
+// Test that decoded messages encode back to the original octets
+func TestEncodeRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{
+			name:    "CAT 048 plot",
+			payload: "30000e" + "f0" + "0102" + "3d1234" + "a0" + "40002000",
+		},
+		{
+			name: "Several blocks and records",
+			payload: "30000a" + "90" + "0102" + "40002000" +
+				"22000b" + "f0" + "0102" + "01" + "3d1234" + "28",
+		},
+		{
+			name:    "CAT 001 plot and track",
+			payload: "01000b" + "c0" + "0102" + "20" + "c0" + "0102" + "a0",
+		},
+		{
+			name:    "Unsupported category",
+			payload: "c80006" + "010203",
+		},
+		{
+			name:    "Trailing octets",
+			payload: "30000a" + "90" + "0102" + "40002000" + "ff",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := hex.DecodeString(tt.payload)
+			assertRoundTrip(t, payload)
+		})
+	}
+}
+
+// Test synthesising a record from hand-written JSON
+func TestEncodeFromJSON(t *testing.T) {
+	message := `{"blocks": [{"category": 48, "records": [{"data_items": {
+		"data_source_id": {"sac": 1, "sic": 2},
+		"time_of_day": 27354.6015625,
+		"measured_position_polar": {"rho_nm": 64, "theta_deg": 45},
+		"mode3a": {"validated": true, "code": "7000"},
+		"aircraft_id": "AIR123"
+	}}]}]}`
+
+	encoded, err := EncodeJSON([]byte(message))
+	if err != nil {
+		t.Fatalf("EncodeJSON() error = %v", err)
+	}
+
+	expected := "300016" +
+		"d9" + "40" + // FSPEC: FRN 1, 2, 4, 5 and 9
+		"0102" + // I048/010
+		"356d4d" + // I048/140
+		"40002000" + // I048/040
+		"0e00" + // I048/070
+		"0494b1cb3820" // I048/240
+	if got := hex.EncodeToString(encoded); got != expected {
+		t.Errorf("EncodeJSON() = %s, want %s", got, expected)
+	}
+}
+
+// Test that values the UAP cannot carry are rejected
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		items string
+		want  string
+	}{
+		{
+			name:  "Unknown item",
+			items: `{"data_source_id": {"sac": 1, "sic": 2}, "bogus": 1}`,
+			want:  `unknown items "bogus"`,
+		},
+		{
+			name:  "Unknown field",
+			items: `{"data_source_id": {"sac": 1, "sic": 2, "sid": 3}}`,
+			want:  `unknown fields "sid"`,
+		},
+		{
+			name:  "Value out of range",
+			items: `{"data_source_id": {"sac": 256, "sic": 2}}`,
+			want:  "field sac: 256 is out of range",
+		},
+		{
+			name:  "Wrong type",
+			items: `{"mode3a": {"code": 7000}}`,
+			want:  "field code: want a string",
+		},
+		{
+			name:  "Bad callsign",
+			items: `{"aircraft_id": "air123"}`,
+			want:  "cannot be encoded",
+		},
+		{
+			name:  "Empty record",
+			items: `{}`,
+			want:  "record has no items",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := `{"blocks": [{"category": 48, "records": [{"data_items": ` + tt.items + `}]}]}`
+			_, err := EncodeJSON([]byte(message))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EncodeJSON() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package asterix
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnterminatedFSPEC reports a record whose FSPEC runs to the end of the
+// data block
+var ErrUnterminatedFSPEC = errors.New("unterminated FSPEC")
+
+// LengthError reports a data block whose LEN field is shorter than its header
+// or runs past the end of the datagram
+type LengthError struct {
+	Offset    int // position of the block in the datagram
+	Length    int // value of the LEN field
+	Remaining int // octets from Offset to the end of the datagram
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("invalid length field at offset %d: %d (remaining: %d)", e.Offset, e.Length, e.Remaining)
+}
+
+// TrailingError reports octets after the last data block that are too few to
+// form another
+type TrailingError struct {
+	Offset int // position of the first trailing octet
+	Count  int
+}
+
+func (e *TrailingError) Error() string {
+	return fmt.Sprintf("%d trailing octets at offset %d", e.Count, e.Offset)
+}
+
+// RecordError reports a record that could not be fully decoded. Undecoded is
+// 0 when decoding carried on with the next record, and otherwise counts the
+// octets of the block left after the start of the record.
+type RecordError struct {
+	Index     int // position of the record in the block
+	Offset    int // position of the record in the datagram
+	Undecoded int
+	Err       error
+}
+
+func (e *RecordError) Error() string {
+	if e.Undecoded == 0 {
+		return fmt.Sprintf("record %d at offset %d: %v", e.Index, e.Offset, e.Err)
+	}
+	return fmt.Sprintf("record %d at offset %d: %v (%d octets not decoded)", e.Index, e.Offset, e.Err, e.Undecoded)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ItemError reports a data item that could not be decoded
+type ItemError struct {
+	Category int
+	ID       string // item ID within the category, such as "040" or "RE"
+	Err      error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("I%03d/%s: %v", e.Category, e.ID, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// FRNError reports an FSPEC bit set for an FRN missing from the UAP, after
+// which the length of the record is unknown
+type FRNError struct {
+	FRN      int
+	Category int
+	Edition  string
+	UAP      string // name of the UAP for categories with several
+}
+
+func (e *FRNError) Error() string {
+	if e.UAP != "" {
+		return fmt.Sprintf("FRN %d is not defined in CAT %03d edition %s %s UAP", e.FRN, e.Category, e.Edition, e.UAP)
+	}
+	return fmt.Sprintf("FRN %d is not defined in CAT %03d edition %s", e.FRN, e.Category, e.Edition)
+}
+
+// TruncatedError reports an item that runs past the end of the data
+type TruncatedError struct {
+	Need int // octets the item needs
+	Have int // octets left
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("truncated: need %d octets, have %d", e.Need, e.Have)
+}
+
+// Errors lists the errors of several items of a record, in FRN order
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// joinErrors returns nil for no errors, the error itself for one, and Errors
+// for several
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return Errors(errs)
+}
+
+// errorText returns the message of an error, or "" for nil
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// contentError reports an item whose length is known but whose content could
+// not be decoded. Decoding continues after such items.
+type contentError struct {
+	err error
+}
+
+func (e *contentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *contentError) Unwrap() error {
+	return e.err
+}
+
+// isContentError reports whether an item error leaves the item's length
+// known, so decoding can carry on after it
+func isContentError(err error) bool {
+	var ce *contentError
+	return errors.As(err, &ce)
+}
@@ -0,0 +1,164 @@
+package asterix
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+)
+
+// fuzzSeeds are datagrams of several categories used to seed the fuzz tests
+var fuzzSeeds = []string{
+	"300009" + "c0" + "0102" + "356d4d",
+	"30000e" + "900201" + "01004000" + "02" + "0102" + "ff",
+	"300014" + "f0" + "0102" + "3d1234" + "a0" + "40002000" + "81" + "10" + "0102" + "002a",
+	"150006" + "80" + "0102",
+	"f0000d" + "d0" + "0102" + "01" + "055241444152",
+	"c80006" + "010203",
+	"ffee",
+}
+
+// Test that any payload decodes without panicking, that the message marshals
+// as it is logged, and that messages which decode and encode cleanly decode
+// again to the same items
+func FuzzDecode(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		payload, _ := hex.DecodeString(seed)
+		f.Add(payload)
+	}
+
+	f.Fuzz(func(t *testing.T, payload []byte) {
+		Detect(payload, nil, nil)
+		msg, err := Decode(payload, nil)
+		checkLayout(t, msg, len(payload))
+		data, marshalErr := json.Marshal(msg)
+		if marshalErr != nil {
+			t.Fatalf("json.Marshal() error = %v", marshalErr)
+		}
+		if err != nil {
+			return
+		}
+
+		encoded, err := Encode(msg)
+		if err != nil {
+			return // values such as unknown ICAO characters do not encode
+		}
+		again, err := Decode(encoded, nil)
+		if err != nil {
+			t.Fatalf("Decode(Encode()) error = %v", err)
+		}
+		againData, _ := json.Marshal(again)
+		if !bytes.Equal(stripLayout(t, againData), stripLayout(t, data)) {
+			t.Errorf("Decode(Encode()) = %s\nwant %s", againData, data)
+		}
+	})
+}
+
+// checkLayout checks that the blocks and records of a decoded message lie
+// within the payload, one after the other
+func checkLayout(t *testing.T, msg *Message, size int) {
+	t.Helper()
+
+	offset := 0
+	for i, block := range msg.Blocks {
+		if block.Offset != offset || block.Length < 3 || offset+block.Length > size {
+			t.Fatalf("block %d at offset %d of %d octets, want offset %d within %d", i, block.Offset, block.Length, offset, size)
+		}
+		end := offset + 3
+		for _, record := range block.Records {
+			if record.Offset != end || record.Length < 0 || end+record.Length > offset+block.Length {
+				t.Fatalf("block %d record %d at offset %d of %d octets runs past the block", i, record.Index, record.Offset, record.Length)
+			}
+			end += record.Length
+		}
+		offset += block.Length
+	}
+	if trailing, _ := base64.StdEncoding.DecodeString(msg.Trailing); offset+len(trailing) != size {
+		t.Fatalf("blocks end at %d with %d trailing octets, want %d", offset, len(trailing), size)
+	}
+}
+
+// stripLayout drops the FSPEC and lengths of a marshalled message, which
+// change when unset FX bits and spare bits are normalised by encoding
+func stripLayout(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var msg struct {
+		Blocks []struct {
+			Category int
+			Edition  string
+			Records  []struct {
+				Items map[string]interface{} `json:"data_items"`
+			}
+			Raw string
+		}
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	stripped, _ := json.Marshal(msg)
+	return stripped
+}
+
+// Test that encoding any JSON message fails cleanly instead of panicking
+func FuzzEncodeJSON(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		payload, _ := hex.DecodeString(seed)
+		data, _ := json.Marshal(decodeMessage(payload, nil))
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		EncodeJSON(data)
+	})
+}
+
+// Test that FSPEC parsing stays within the data and stops at the first octet
+// with a clear FX bit
+func FuzzParseFSPEC(f *testing.F) {
+	for _, seed := range []string{"", "80", "fd02", "ffffff00", "ffffffffffffffffffffffffff"} {
+		data, _ := hex.DecodeString(seed)
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		fspec, n := parseFSPEC(data)
+		if n != len(fspec) || n > len(data) || !bytes.Equal(fspec, data[:n]) {
+			t.Fatalf("parseFSPEC(%x) = %x, %d", data, fspec, n)
+		}
+		for i, b := range fspec[:max(n-1, 0)] {
+			if b&0x01 == 0 {
+				t.Fatalf("parseFSPEC(%x) continues past octet %d with a clear FX bit", data, i)
+			}
+		}
+	})
+}
+
+// Test that records of every loaded category decode within their data, and
+// that a record decoded without error decodes alone to the same length
+func FuzzDecodeRecord(f *testing.F) {
+	for category := range loadedSpecs {
+		f.Add(uint8(category), []byte{0x80, 0x01, 0x02})
+	}
+
+	f.Fuzz(func(t *testing.T, category uint8, data []byte) {
+		spec := LookupSpec(int(category), "")
+		if spec == nil {
+			return
+		}
+
+		record, err := decodeRecord(data, spec)
+		if record.Length < 0 || record.Length > len(data) {
+			t.Fatalf("decodeRecord() length = %d of %d octets", record.Length, len(data))
+		}
+		if err != nil {
+			return
+		}
+
+		again, err := decodeRecord(data[:record.Length], spec)
+		if err != nil || again.Length != record.Length {
+			t.Fatalf("decodeRecord() of the record alone = %d octets, %v, want %d", again.Length, err, record.Length)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+package asterix
+
+// RoleValue is the decoded value of a field with a role
+type RoleValue struct {
+	Item  *Item
+	Field *Field
+	Value interface{}
+}
+
+// Roles holds the values of the fields with a role in a record, keyed by
+// role. Each list is in FRN order, so later items, such as high-resolution
+// positions, come last.
+type Roles map[string][]RoleValue
+
+// Roles collects the values of the fields with a role in a record
+func (s *Spec) Roles(record *Record) Roles {
+	uap := s.UAP
+	if record.UAP != "" && s.UAPs[record.UAP] != nil {
+		uap = s.UAPs[record.UAP]
+	}
+
+	values := make(Roles)
+	for _, id := range uap {
+		item := s.Items[id]
+		if item == nil || len(item.roles) == 0 {
+			continue
+		}
+		if record.typed != nil {
+			s.typed.addRoles(values, record.typed, item)
+		} else if value, ok := record.Items[item.Name]; ok {
+			values.add(item, value)
+		}
+	}
+	return values
+}
+
+// add adds the values of the fields with a role in a decoded item
+func (r Roles) add(item *Item, value interface{}) {
+	fields, isMap := value.(map[string]interface{})
+	for _, f := range item.roles {
+		v := value
+		if isMap {
+			var ok bool
+			if v, ok = fields[f.Name]; !ok {
+				continue
+			}
+		}
+		r[f.Role] = append(r[f.Role], RoleValue{item, f, v})
+	}
+}
+
+// LastInt returns the last integer value of a role
+func (r Roles) LastInt(role string) (int, bool) {
+	values := r[role]
+	if len(values) == 0 {
+		return 0, false
+	}
+	v, ok := values[len(values)-1].Value.(int)
+	return v, ok
+}
+
+// LastFloat returns the last numeric value of a role
+func (r Roles) LastFloat(role string) (float64, bool) {
+	values := r[role]
+	if len(values) == 0 {
+		return 0, false
+	}
+	return values[len(values)-1].Float()
+}
+
+// LastString returns the last string value of a role
+func (r Roles) LastString(role string) (string, bool) {
+	values := r[role]
+	if len(values) == 0 {
+		return "", false
+	}
+	v, ok := values[len(values)-1].Value.(string)
+	return v, ok
+}
+
+// Float returns a numeric value as float64
+func (v RoleValue) Float() (float64, bool) {
+	return toFloat(v.Value)
+}
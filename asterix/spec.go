@@ -0,0 +1,711 @@
+package asterix
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Item formats defined by the ASTERIX specification
+const (
+	FormatFixed      = "fixed"      // fixed number of octets
+	FormatExtended   = "extended"   // octet groups chained by an FX bit
+	FormatRepetitive = "repetitive" // one-octet REP factor followed by REP fixed-length elements
+	FormatExplicit   = "explicit"   // one-octet length indicator (including itself) followed by content
+	FormatCompound   = "compound"   // primary subfield bitmap followed by the present subfields
+)
+
+// Field types used to interpret the bits of an item
+const (
+	FieldUint  = "uint"  // unsigned integer, scaled by lsb if set
+	FieldInt   = "int"   // two's complement integer, scaled by lsb if set
+	FieldBool  = "bool"  // single-bit flag
+	FieldOctal = "octal" // Mode 1/2/3/A code printed as octal digits
+	FieldHex   = "hex"   // ICAO 24-bit address or similar printed as hex digits
+	FieldICAO6 = "icao6" // ICAO 6-bit character string (callsigns)
+	FieldASCII = "ascii" // 8-bit character string
+	FieldRaw   = "raw"   // opaque octets, base64 encoded
+	FieldSpare = "spare" // unused bits, not decoded
+)
+
+// Field roles that Spec.Roles reports, such as to a track table
+var fieldRoles = map[string]bool{
+	"sac":              true,
+	"sic":              true,
+	"track_number":     true,
+	"target_address":   true, // ICAO 24-bit address
+	"callsign":         true,
+	"mode3a":           true,
+	"flight_level":     true,
+	"geometric_height": true, // feet
+	"latitude":         true,
+	"longitude":        true,
+	"local_position":   true, // polar or Cartesian position relative to the sensor
+}
+
+//go:embed specs/*.yaml
+var embeddedSpecs embed.FS
+
+// loadedSpecs holds the User Application Profile of each loaded edition,
+// keyed by category and edition
+var loadedSpecs = make(map[int]map[string]*Spec)
+
+// defaultEditions holds the latest loaded edition of each category,
+// used when Editions do not choose one
+var defaultEditions = make(map[int]string)
+
+func init() {
+	if err := loadSpecs(embeddedSpecs, "specs"); err != nil {
+		panic(fmt.Sprintf("invalid embedded ASTERIX specification: %v", err))
+	}
+}
+
+// Spec describes the User Application Profile of one category edition
+type Spec struct {
+	Category int                 `yaml:"category"`
+	Edition  string              `yaml:"edition"`
+	Title    string              `yaml:"title"`
+	UAP      []string            `yaml:"uap"`                    // item IDs in FRN order, "-" marks a spare FRN
+	UAPs     map[string][]string `yaml:"uaps,omitempty"`         // alternative UAPs chosen by the selector
+	Selector *UAPSelector        `yaml:"uap_selector,omitempty"` // chooses the UAP of each record
+	Items    map[string]*Item    `yaml:"items"`
+
+	typed    *typedLayout // decodes records into the typed record of the edition, if any
+	typedErr error        // why records of an edition with a typed record decode into Items
+}
+
+// UAPSelector chooses the UAP of a record from a field of an item
+// that has the same FRN in every UAP, such as the CAT 001 TYP bit
+type UAPSelector struct {
+	Item    string         `yaml:"item"`
+	Field   string         `yaml:"field"`
+	Default string         `yaml:"default"` // name of the uap list, used until the item is decoded
+	Values  map[int]string `yaml:"values"`  // field value to UAP name; other values keep the default
+
+	frn int
+}
+
+// Item describes the layout of a data item, compound subfield or
+// explicit item content
+type Item struct {
+	ID        string   `yaml:"-"`
+	Name      string   `yaml:"name"`
+	Title     string   `yaml:"title,omitempty"`
+	Format    string   `yaml:"format"`
+	Length    int      `yaml:"length,omitempty"`    // fixed: octets; repetitive: octets per element
+	Fields    []*Field `yaml:"fields,omitempty"`    // fixed and repetitive layout
+	Parts     []*Part  `yaml:"parts,omitempty"`     // extended layout, one entry per FX-chained part
+	Repeat    bool     `yaml:"repeat,omitempty"`    // extended: the last part repeats indefinitely
+	Bulk      bool     `yaml:"bulk,omitempty"`      // repetitive: summarise the elements instead of listing them
+	ModeSMB   bool     `yaml:"mode_s_mb,omitempty"` // repetitive: elements are Comm-B MB data with BDS1 and BDS2
+	Subfields []*Item  `yaml:"subfields,omitempty"` // compound layout in primary subfield order, null for spare
+	Content   *Item    `yaml:"content,omitempty"`   // explicit: layout of the octets after the length indicator
+
+	timeOfDay bool     // fixed item holding a single time_of_day field
+	roles     []*Field // fields with a role, in layout order
+}
+
+// Part describes one FX-terminated part of an extended item
+type Part struct {
+	Length int      `yaml:"length,omitempty"` // octets including the FX bit, defaults to 1
+	Fields []*Field `yaml:"fields"`           // layout of all bits except FX
+}
+
+// Field describes a bit field within an item
+type Field struct {
+	Name   string `yaml:"name,omitempty"`
+	Bits   int    `yaml:"bits"`
+	Type   string `yaml:"type,omitempty"`   // defaults to uint
+	LSB    string `yaml:"lsb,omitempty"`    // scale factor such as "1/128" or "180/2^23"
+	Unit   string `yaml:"unit,omitempty"`   // unit of the scaled value
+	Invert bool   `yaml:"invert,omitempty"` // bool: true when the bit is clear
+
+	// TimeOfDay marks seconds since midnight UTC, which are also reported
+	// as an absolute time reconstructed from the receive time
+	TimeOfDay bool `yaml:"time_of_day,omitempty"`
+
+	Role string `yaml:"role,omitempty"` // meaning of the field, reported by Spec.Roles
+
+	scale float64
+}
+
+// LoadSpecDir loads every *.yaml specification in dir, replacing any
+// previously loaded definition for the same category and edition
+func LoadSpecDir(dir string) error {
+	return loadSpecs(os.DirFS(dir), ".")
+}
+
+// loadSpecs parses and registers every *.yaml specification in dir
+func loadSpecs(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.yaml")))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		spec, err := parseSpec(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+
+		registerSpec(spec)
+	}
+
+	return nil
+}
+
+// Expansion defines the content of the Reserved Expansion or Special
+// Purpose field of a category, such as a vendor's REF definition
+type Expansion struct {
+	Category int      `yaml:"category"`
+	Item     string   `yaml:"item"`               // RE or SP
+	Editions []string `yaml:"editions,omitempty"` // defaults to every loaded edition
+	Content  *Item    `yaml:"content"`            // layout of the octets after the length indicator
+}
+
+// LoadExpansionDir loads every *.yaml expansion definition in dir and
+// applies it to the specifications loaded so far
+func LoadExpansionDir(dir string) error {
+	return loadExpansions(os.DirFS(dir), ".")
+}
+
+// loadExpansions parses and applies every *.yaml expansion definition
+// in dir
+func loadExpansions(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, filepath.ToSlash(filepath.Join(dir, "*.yaml")))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+
+		if err := applyExpansion(data); err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+	}
+
+	return nil
+}
+
+// applyExpansion parses an expansion definition and sets it as the
+// content of the RE or SP item of each targeted edition
+func applyExpansion(data []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var exp Expansion
+	if err := decoder.Decode(&exp); err != nil {
+		return fmt.Errorf("failed to parse expansion: %w", err)
+	}
+	if exp.Item != "RE" && exp.Item != "SP" {
+		return fmt.Errorf("expansion item must be RE or SP, not %q", exp.Item)
+	}
+	if exp.Content == nil {
+		return fmt.Errorf("expansion needs a content layout")
+	}
+
+	editions := exp.Editions
+	if len(editions) == 0 {
+		for edition := range loadedSpecs[exp.Category] {
+			editions = append(editions, edition)
+		}
+		if len(editions) == 0 {
+			return fmt.Errorf("CAT %03d is not loaded", exp.Category)
+		}
+		sort.Strings(editions)
+	}
+
+	// Check every edition before changing any, so a bad definition leaves
+	// the specifications untouched
+	items := make([]*Item, len(editions))
+	for i, edition := range editions {
+		spec := LookupSpec(exp.Category, edition)
+		if spec == nil {
+			return fmt.Errorf("CAT %03d edition %s is not loaded", exp.Category, edition)
+		}
+		item := spec.Items[exp.Item]
+		if item == nil || item.Format != FormatExplicit {
+			return fmt.Errorf("CAT %03d edition %s has no explicit %s item", exp.Category, edition, exp.Item)
+		}
+
+		updated := *item
+		updated.Content = exp.Content
+		if i > 0 {
+			// The content is shared and already prepared
+			items[i] = &updated
+			continue
+		}
+		if err := updated.prepare(); err != nil {
+			return fmt.Errorf("I%03d/%s: %w", exp.Category, exp.Item, err)
+		}
+		items[i] = &updated
+	}
+
+	for i, edition := range editions {
+		spec := loadedSpecs[exp.Category][edition]
+		spec.Items[exp.Item] = items[i]
+		spec.bindTyped()
+	}
+	return nil
+}
+
+// registerSpec makes a specification available to the decoder and
+// updates the default edition of its category
+func registerSpec(spec *Spec) {
+	spec.bindTyped()
+
+	editions := loadedSpecs[spec.Category]
+	if editions == nil {
+		editions = make(map[string]*Spec)
+		loadedSpecs[spec.Category] = editions
+	}
+	editions[spec.Edition] = spec
+
+	if current, ok := defaultEditions[spec.Category]; !ok || compareEditions(spec.Edition, current) > 0 {
+		defaultEditions[spec.Category] = spec.Edition
+	}
+}
+
+// LookupSpec returns the specification of a category edition, or of
+// the default edition when edition is empty. It returns nil if the category
+// or edition is not loaded.
+func LookupSpec(category int, edition string) *Spec {
+	if edition == "" {
+		edition = defaultEditions[category]
+	}
+	return loadedSpecs[category][edition]
+}
+
+// compareEditions orders edition numbers such as "1.9" and "1.10" by their
+// dot-separated components, returning -1, 0 or 1
+func compareEditions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// parseSpec parses and validates a YAML category specification
+func parseSpec(data []byte) (*Spec, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var spec Spec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("failed to parse specification: %w", err)
+	}
+
+	if err := spec.prepare(); err != nil {
+		return nil, err
+	}
+
+	return &spec, nil
+}
+
+// prepare validates the specification and resolves derived values
+func (s *Spec) prepare() error {
+	if s.Category < 1 || s.Category > 255 {
+		return fmt.Errorf("invalid category %d", s.Category)
+	}
+	if len(s.UAP) == 0 {
+		return fmt.Errorf("CAT %03d: empty UAP", s.Category)
+	}
+
+	names := make(map[string]string)
+	for id, item := range s.Items {
+		if item == nil {
+			return fmt.Errorf("CAT %03d: item %s has no definition", s.Category, id)
+		}
+		item.ID = id
+		if err := item.prepare(); err != nil {
+			return fmt.Errorf("CAT %03d: item %s: %w", s.Category, id, err)
+		}
+		if other, exists := names[item.Name]; exists {
+			return fmt.Errorf("CAT %03d: items %s and %s share the name %q", s.Category, other, id, item.Name)
+		}
+		names[item.Name] = id
+	}
+
+	if err := s.prepareUAP(s.UAP); err != nil {
+		return err
+	}
+	for name, uap := range s.UAPs {
+		if err := s.prepareUAP(uap); err != nil {
+			return fmt.Errorf("%w in UAP %q", err, name)
+		}
+	}
+
+	if s.Selector != nil {
+		if err := s.Selector.prepare(s); err != nil {
+			return fmt.Errorf("CAT %03d: uap_selector: %w", s.Category, err)
+		}
+	} else if len(s.UAPs) > 0 {
+		return fmt.Errorf("CAT %03d: alternative UAPs need a uap_selector", s.Category)
+	}
+
+	return nil
+}
+
+// prepareUAP checks that every FRN of a UAP refers to a defined item
+func (s *Spec) prepareUAP(uap []string) error {
+	for i, id := range uap {
+		if id == "-" {
+			continue
+		}
+		if _, ok := s.Items[id]; !ok {
+			return fmt.Errorf("CAT %03d: FRN %d refers to undefined item %s", s.Category, i+1, id)
+		}
+	}
+	return nil
+}
+
+// prepare checks that the selector item has the same FRN in every UAP and
+// that every value names a UAP
+func (sel *UAPSelector) prepare(s *Spec) error {
+	if sel.Default == "" {
+		return fmt.Errorf("missing default UAP name")
+	}
+	item, ok := s.Items[sel.Item]
+	if !ok {
+		return fmt.Errorf("undefined item %s", sel.Item)
+	}
+	if !item.hasField(sel.Field) {
+		return fmt.Errorf("item %s has no field %q", sel.Item, sel.Field)
+	}
+
+	for i, id := range s.UAP {
+		if id == sel.Item {
+			sel.frn = i + 1
+		}
+	}
+	if sel.frn == 0 {
+		return fmt.Errorf("item %s is not in the default UAP", sel.Item)
+	}
+	for name, uap := range s.UAPs {
+		if len(uap) < sel.frn || uap[sel.frn-1] != sel.Item {
+			return fmt.Errorf("UAP %q does not have item %s at FRN %d", name, sel.Item, sel.frn)
+		}
+	}
+
+	for value, name := range sel.Values {
+		if _, ok := s.UAPs[name]; !ok && name != sel.Default {
+			return fmt.Errorf("value %d refers to undefined UAP %q", value, name)
+		}
+	}
+	return nil
+}
+
+// item returns the item at the given FRN of the default UAP, or nil for
+// spare or undefined FRNs
+func (s *Spec) item(frn int) *Item {
+	return s.lookup(s.UAP, frn)
+}
+
+// lookup returns the item at the given FRN of a UAP, or nil for spare or
+// undefined FRNs
+func (s *Spec) lookup(uap []string, frn int) *Item {
+	if frn < 1 || frn > len(uap) {
+		return nil
+	}
+	return s.Items[uap[frn-1]]
+}
+
+// selectUAP returns the name and item list of the UAP chosen by the decoded
+// value of the selector item
+func (s *Spec) selectUAP(value interface{}) (string, []string) {
+	sel := s.Selector
+	if fields, ok := value.(map[string]interface{}); ok {
+		value = fields[sel.Field]
+	}
+
+	var key int
+	switch v := value.(type) {
+	case int:
+		key = v
+	case float64:
+		key = int(v)
+	case bool:
+		if v {
+			key = 1
+		}
+	}
+
+	if name, ok := sel.Values[key]; ok && name != sel.Default {
+		return name, s.UAPs[name]
+	}
+	return sel.Default, s.UAP
+}
+
+// hasField reports whether a fixed, repetitive or extended item defines
+// the named field
+func (it *Item) hasField(name string) bool {
+	for _, f := range it.Fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	for _, part := range it.Parts {
+		for _, f := range part.Fields {
+			if f.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prepare validates an item definition against its format
+func (it *Item) prepare() error {
+	if it.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	if it.Bulk && it.Format != FormatRepetitive {
+		return fmt.Errorf("bulk only applies to repetitive items")
+	}
+	if it.ModeSMB {
+		if err := it.prepareModeSMB(); err != nil {
+			return err
+		}
+	}
+
+	switch it.Format {
+	case FormatFixed, FormatRepetitive:
+		if it.Length < 1 {
+			return fmt.Errorf("%s item needs a positive length", it.Format)
+		}
+		if err := prepareFields(it.Fields, it.Length*8); err != nil {
+			return err
+		}
+		for _, f := range it.Fields {
+			if f.TimeOfDay {
+				if it.Format != FormatFixed || !isSingleField(it.Fields) {
+					return fmt.Errorf("time_of_day only applies to the single field of a fixed item")
+				}
+				it.timeOfDay = true
+			}
+			if f.Role != "" {
+				if it.Format != FormatFixed {
+					return fmt.Errorf("roles only apply to fixed and extended items")
+				}
+				it.roles = append(it.roles, f)
+			}
+		}
+		return nil
+
+	case FormatExtended:
+		for i, part := range it.Parts {
+			if part.Length == 0 {
+				part.Length = 1
+			}
+			if len(part.Fields) == 0 {
+				return fmt.Errorf("part %d: missing fields", i+1)
+			}
+			if err := prepareFields(part.Fields, part.Length*8-1); err != nil {
+				return fmt.Errorf("part %d: %w", i+1, err)
+			}
+			for _, f := range part.Fields {
+				if f.TimeOfDay {
+					return fmt.Errorf("part %d: time_of_day only applies to the single field of a fixed item", i+1)
+				}
+				if f.Role != "" {
+					it.roles = append(it.roles, f)
+				}
+			}
+		}
+		if it.Repeat && len(it.Parts) == 0 {
+			return fmt.Errorf("repeating extended item needs at least one part")
+		}
+		if it.Repeat && len(it.roles) > 0 {
+			return fmt.Errorf("roles only apply to fixed and extended items")
+		}
+		return nil
+
+	case FormatExplicit:
+		if it.Content != nil {
+			if it.Content.Name == "" {
+				it.Content.Name = it.Name
+			}
+			if err := it.Content.prepare(); err != nil {
+				return err
+			}
+			if it.Content.timeOfDay || len(it.Content.roles) > 0 {
+				return fmt.Errorf("time_of_day and roles only apply to top-level items")
+			}
+		}
+		return nil
+
+	case FormatCompound:
+		if len(it.Subfields) == 0 {
+			return fmt.Errorf("compound item needs subfields")
+		}
+		for i, sub := range it.Subfields {
+			if sub == nil {
+				continue
+			}
+			if err := sub.prepare(); err != nil {
+				return fmt.Errorf("subfield %d (%s): %w", i+1, sub.Name, err)
+			}
+			if sub.timeOfDay || len(sub.roles) > 0 {
+				return fmt.Errorf("subfield %d (%s): time_of_day and roles only apply to top-level items", i+1, sub.Name)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown format %q", it.Format)
+}
+
+// prepareFields validates a field layout covering exactly the given number
+// of bits. An empty layout is allowed and decodes as raw octets.
+func prepareFields(fields []*Field, bits int) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, f := range fields {
+		if err := f.prepare(); err != nil {
+			return err
+		}
+		total += f.Bits
+	}
+
+	if total != bits {
+		return fmt.Errorf("fields cover %d bits, want %d", total, bits)
+	}
+	return nil
+}
+
+// prepare validates a field and parses its scale factor
+func (f *Field) prepare() error {
+	if f.Type == "" {
+		f.Type = FieldUint
+	}
+	if f.Bits < 1 {
+		return fmt.Errorf("field %q: bits must be positive", f.Name)
+	}
+	if f.Name == "" && f.Type != FieldSpare {
+		return fmt.Errorf("field of %d bits: missing name", f.Bits)
+	}
+
+	switch f.Type {
+	case FieldUint, FieldInt:
+		if f.Bits > 64 {
+			return fmt.Errorf("field %q: integers are limited to 64 bits", f.Name)
+		}
+	case FieldBool:
+		if f.Bits != 1 {
+			return fmt.Errorf("field %q: bool must be 1 bit", f.Name)
+		}
+	case FieldOctal:
+		if f.Bits%3 != 0 {
+			return fmt.Errorf("field %q: octal needs a multiple of 3 bits", f.Name)
+		}
+	case FieldHex:
+		if f.Bits%4 != 0 || f.Bits > 64 {
+			return fmt.Errorf("field %q: hex needs a multiple of 4 bits up to 64", f.Name)
+		}
+	case FieldICAO6:
+		if f.Bits%6 != 0 {
+			return fmt.Errorf("field %q: icao6 needs a multiple of 6 bits", f.Name)
+		}
+	case FieldASCII, FieldRaw:
+		if f.Bits%8 != 0 {
+			return fmt.Errorf("field %q: %s needs a multiple of 8 bits", f.Name, f.Type)
+		}
+	case FieldSpare:
+	default:
+		return fmt.Errorf("field %q: unknown type %q", f.Name, f.Type)
+	}
+
+	if f.Role != "" && !fieldRoles[f.Role] {
+		return fmt.Errorf("field %q: unknown role %q", f.Name, f.Role)
+	}
+
+	if f.TimeOfDay && (f.Type != FieldUint || f.LSB == "") {
+		return fmt.Errorf("field %q: time_of_day needs an unsigned field with an lsb in seconds", f.Name)
+	}
+
+	if f.LSB != "" {
+		if f.Type != FieldUint && f.Type != FieldInt {
+			return fmt.Errorf("field %q: lsb only applies to uint and int", f.Name)
+		}
+		scale, err := parseLSB(f.LSB)
+		if err != nil {
+			return fmt.Errorf("field %q: %w", f.Name, err)
+		}
+		f.scale = scale
+	}
+
+	return nil
+}
+
+// parseLSB parses a scale factor written as a number, a fraction or a power
+// of two, e.g. "0.25", "1/128", "180/2^23" or "2^-14"
+func parseLSB(s string) (float64, error) {
+	num, den, isFraction := strings.Cut(s, "/")
+
+	n, err := parseLSBTerm(num)
+	if err != nil {
+		return 0, fmt.Errorf("invalid lsb %q", s)
+	}
+	if !isFraction {
+		return n, nil
+	}
+
+	d, err := parseLSBTerm(den)
+	if err != nil || d == 0 {
+		return 0, fmt.Errorf("invalid lsb %q", s)
+	}
+	return n / d, nil
+}
+
+// parseLSBTerm parses a number or a power such as "2^23"
+func parseLSBTerm(s string) (float64, error) {
+	base, exp, isPower := strings.Cut(strings.TrimSpace(s), "^")
+
+	b, err := strconv.ParseFloat(strings.TrimSpace(base), 64)
+	if err != nil || !isPower {
+		return b, err
+	}
+
+	e, err := strconv.ParseFloat(strings.TrimSpace(exp), 64)
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(b, e), nil
+}
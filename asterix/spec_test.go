@@ -0,0 +1,384 @@
+package asterix
+
+import (
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// testSpec exercises every item format of the interpreter
+const testSpec = `
+category: 200
+edition: "1.0"
+title: Interpreter test category
+uap: ["010", "020", "030", "040", "050", "-", "060", "070"]
+items:
+  "010":
+    name: data_source_id
+    format: fixed
+    length: 2
+    fields:
+      - {name: sac, bits: 8}
+      - {name: sic, bits: 8}
+  "020":
+    name: descriptor
+    format: extended
+    parts:
+      - fields:
+          - {name: typ, bits: 3}
+          - {name: sim, bits: 1, type: bool}
+          - {bits: 3, type: spare}
+      - fields:
+          - {name: tst, bits: 1, type: bool}
+          - {bits: 6, type: spare}
+  "030":
+    name: counts
+    format: repetitive
+    length: 2
+    fields:
+      - {name: typ, bits: 5}
+      - {name: count, bits: 11}
+  "040":
+    name: status
+    format: compound
+    subfields:
+      - {name: height, format: fixed, length: 2, fields: [{name: height, bits: 16, type: int, lsb: 6.25}]}
+      - null
+      - {name: callsign, format: fixed, length: 6, fields: [{name: callsign, bits: 48, type: icao6}]}
+  "050":
+    name: special
+    format: explicit
+  "060":
+    name: mode3a
+    format: fixed
+    length: 2
+    fields:
+      - {bits: 4, type: spare}
+      - {name: code, bits: 12, type: octal}
+  "070":
+    name: expansion
+    format: explicit
+    content:
+      format: fixed
+      length: 2
+      fields:
+        - {name: value, bits: 16}
+`
+
+// Test that the shipped specifications load and cover their UAPs
+func TestEmbeddedSpecs(t *testing.T) {
+	for _, category := range []int{1, 2, 4, 8, 10, 19, 20, 21, 23, 25, 34, 48, 62, 63, 240} {
+		if LookupSpec(category, "") == nil {
+			t.Errorf("no specification loaded for CAT %03d", category)
+			continue
+		}
+
+		for edition, spec := range loadedSpecs[category] {
+			uaps := map[string][]string{"default": spec.UAP}
+			for name, uap := range spec.UAPs {
+				uaps[name] = uap
+			}
+			for name, uap := range uaps {
+				for frn, id := range uap {
+					if id != "-" && spec.lookup(uap, frn+1) == nil {
+						t.Errorf("CAT %03d edition %s %s UAP FRN %d (%s) has no item", category, edition, name, frn+1, id)
+					}
+				}
+			}
+		}
+	}
+
+	if edition := defaultEditions[21]; edition != "2.4" {
+		t.Errorf("CAT 021 default edition = %q, want latest 2.4", edition)
+	}
+}
+
+// Test edition ordering
+func TestCompareEditions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.10", "1.9", 1},
+		{"0.23", "2.4", -1},
+		{"1.2", "1.2", 0},
+		{"1.2", "1.2.1", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareEditions(tt.a, tt.b); got != tt.expected {
+			t.Errorf("compareEditions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+// Test scale factor parsing
+func TestParseLSB(t *testing.T) {
+	tests := []struct {
+		lsb      string
+		expected float64
+	}{
+		{"0.25", 0.25},
+		{"25", 25},
+		{"1/128", 1.0 / 128},
+		{"360/2^16", 360.0 / 65536},
+		{"2^-14", 1.0 / 16384},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLSB(tt.lsb)
+		if err != nil {
+			t.Errorf("parseLSB(%q) error = %v", tt.lsb, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("parseLSB(%q) = %v, want %v", tt.lsb, got, tt.expected)
+		}
+	}
+
+	if _, err := parseLSB("1/0"); err == nil {
+		t.Error("parseLSB(\"1/0\") should fail")
+	}
+}
+
+// Test that malformed specifications are rejected
+func TestParseSpecErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "Fields do not cover item",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: fixed, length: 2, fields: [{name: b, bits: 8}]}}}`,
+			want: "fields cover 8 bits, want 16",
+		},
+		{
+			name: "UAP refers to missing item",
+			spec: `{category: 1, uap: ["010"], items: {}}`,
+			want: "undefined item 010",
+		},
+		{
+			name: "Unknown field type",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: fixed, length: 1, fields: [{name: b, bits: 8, type: float}]}}}`,
+			want: "unknown type",
+		},
+		{
+			name: "Selector item moves between UAPs",
+			spec: `{category: 1, uap: ["010", "020"], uaps: {b: ["020", "010"]},
+				uap_selector: {item: "020", field: typ, default: a, values: {1: b}},
+				items: {"010": {name: a, format: fixed, length: 1}, "020": {name: b, format: fixed, length: 1, fields: [{name: typ, bits: 8}]}}}`,
+			want: "does not have item 020 at FRN 2",
+		},
+		{
+			name: "Selector refers to missing UAP",
+			spec: `{category: 1, uap: ["020"], uap_selector: {item: "020", field: typ, default: a, values: {1: b}},
+				items: {"020": {name: b, format: fixed, length: 1, fields: [{name: typ, bits: 8}]}}}`,
+			want: "undefined UAP",
+		},
+		{
+			name: "Bulk fixed item",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: fixed, length: 1, bulk: true}}}`,
+			want: "bulk only applies to repetitive items",
+		},
+		{
+			name: "Time of day in a repetitive item",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: repetitive, length: 3, fields: [{name: t, bits: 24, lsb: 1/128, time_of_day: true}]}}}`,
+			want: "time_of_day only applies to the single field of a fixed item",
+		},
+		{
+			name: "Time of day without lsb",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: fixed, length: 3, fields: [{name: t, bits: 24, time_of_day: true}]}}}`,
+			want: "time_of_day needs an unsigned field with an lsb",
+		},
+		{
+			name: "Mode S MB data of the wrong layout",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: repetitive, length: 7, mode_s_mb: true, fields: [{name: mb, bits: 56}]}}}`,
+			want: "mode_s_mb needs 56 bits of MB data, BDS1 and BDS2",
+		},
+		{
+			name: "Unknown role",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: fixed, length: 1, fields: [{name: b, bits: 8, role: squawk}]}}}`,
+			want: `unknown role "squawk"`,
+		},
+		{
+			name: "Role in a repetitive item",
+			spec: `{category: 1, uap: ["010"], items: {"010": {name: a, format: repetitive, length: 1, fields: [{name: b, bits: 8, role: sac}]}}}`,
+			want: "roles only apply to fixed and extended items",
+		},
+		{
+			name: "Unknown key",
+			spec: `{category: 1, uap: [], lenght: 2}`,
+			want: "lenght",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSpec([]byte(tt.spec))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseSpec() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// Test the generic interpreter against each item format
+func TestDecodeRecordFormats(t *testing.T) {
+	spec, err := parseSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("parseSpec() error = %v", err)
+	}
+
+	// FSPEC fa: FRN 1-5 and 7
+	data, _ := hex.DecodeString("fa" +
+		"0102" + // 010: SAC=1 SIC=2
+		"b180" + // 020: TYP=5 SIM=1, FX; TST=1
+		"02" + "0803" + "1005" + // 030: REP=2, (1,3) (2,5)
+		"a0" + "ffe0" + "0494b1cb3820" + // 040: height=-200 ft, callsign
+		"03abcd" + // 050: explicit, 2 octets of content
+		"0fff") // 060: code 7777
+
+	record, err := decodeRecord(data, spec)
+	if err != nil {
+		t.Fatalf("decodeRecord() error = %v", err)
+	}
+	if record.Length != len(data) {
+		t.Errorf("decodeRecord() consumed %d octets, want %d", record.Length, len(data))
+	}
+	if record.Err != nil {
+		t.Errorf("Err = %v", record.Err)
+	}
+
+	expected := map[string]interface{}{
+		"data_source_id": map[string]interface{}{"sac": 1, "sic": 2},
+		"descriptor":     map[string]interface{}{"typ": 5, "sim": true, "tst": true},
+		"counts": []interface{}{
+			map[string]interface{}{"typ": 1, "count": 3},
+			map[string]interface{}{"typ": 2, "count": 5},
+		},
+		"status":  map[string]interface{}{"height": -200.0, "callsign": "AIR123"},
+		"special": "q80=",
+		"mode3a":  "7777",
+	}
+
+	if got := record.Items; !reflect.DeepEqual(got, expected) {
+		t.Errorf("data_items = %#v\nwant %#v", got, expected)
+	}
+}
+
+// Test that records using FRNs missing from the UAP are rejected
+func TestDecodeRecordUndefinedFRN(t *testing.T) {
+	spec, err := parseSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("parseSpec() error = %v", err)
+	}
+
+	// FSPEC 04: FRN 6, which is spare
+	data, _ := hex.DecodeString("040000")
+	var frnErr *FRNError
+	if _, err := decodeRecord(data, spec); !errors.As(err, &frnErr) || frnErr.FRN != 6 {
+		t.Errorf("decodeRecord() error = %v, want FRN 6 not defined", err)
+	}
+}
+
+// Test that explicit items with bad content do not stop the record
+func TestDecodeRecordContentError(t *testing.T) {
+	spec, err := parseSpec([]byte(testSpec))
+	if err != nil {
+		t.Fatalf("parseSpec() error = %v", err)
+	}
+
+	// FSPEC 81 80: FRN 1 and 8; item 070 carries 3 octets instead of 2
+	data, _ := hex.DecodeString("8180" + "0102" + "04010203")
+	record, err := decodeRecord(data, spec)
+	if err != nil {
+		t.Fatalf("decodeRecord() error = %v", err)
+	}
+	if record.Length != len(data) {
+		t.Errorf("Length = %d, want %d", record.Length, len(data))
+	}
+	var itemErr *ItemError
+	if !errors.As(record.Err, &itemErr) || itemErr.ID != "070" {
+		t.Errorf("Err = %v, want I200/070 content error", record.Err)
+	}
+	if record.Items["expansion"] != "AQID" {
+		t.Errorf("expansion = %v, want raw content", record.Items["expansion"])
+	}
+}
+
+// Test that RE and SP layouts loaded from files decode the expansion fields
+func TestExpansion(t *testing.T) {
+	spec := LookupSpec(48, "1.31")
+	original := spec.Items["RE"]
+	t.Cleanup(func() {
+		spec.Items["RE"] = original
+		spec.bindTyped()
+	})
+
+	// FSPEC: FRN 1 and 28 (RE), with 3 octets of vendor content
+	payload, _ := hex.DecodeString("30000d" + "81010102" + "0102" + "04" + "aa0102")
+	items := decodeMessage(payload, nil).Blocks[0].Records[0].ItemValues()
+	if items["reserved_expansion"] != "qgEC" {
+		t.Errorf("reserved_expansion = %v, want raw content by default", items["reserved_expansion"])
+	}
+
+	fsys := fstest.MapFS{
+		"ref/cat048.yaml": {Data: []byte(`
+category: 48
+item: RE
+content:
+  format: fixed
+  length: 3
+  fields:
+    - {name: vendor, bits: 8, type: hex}
+    - {name: mode, bits: 16}
+`)},
+	}
+	if err := loadExpansions(fsys, "ref"); err != nil {
+		t.Fatalf("loadExpansions() error = %v", err)
+	}
+
+	items = decodeMessage(payload, nil).Blocks[0].Records[0].ItemValues()
+	expected := map[string]interface{}{"vendor": "AA", "mode": 258}
+	if got := items["reserved_expansion"]; !reflect.DeepEqual(got, expected) {
+		t.Errorf("reserved_expansion = %#v, want %#v", got, expected)
+	}
+	assertRoundTrip(t, payload)
+
+	errorTests := []struct {
+		name      string
+		expansion string
+		want      string
+	}{
+		{
+			name:      "Not an expansion item",
+			expansion: `{category: 48, item: "010", content: {format: fixed, length: 1}}`,
+			want:      "must be RE or SP",
+		},
+		{
+			name:      "Edition not loaded",
+			expansion: `{category: 48, item: RE, editions: ["0.1"], content: {format: fixed, length: 1}}`,
+			want:      "CAT 048 edition 0.1 is not loaded",
+		},
+		{
+			name:      "Bad content",
+			expansion: `{category: 48, item: SP, content: {format: fixed}}`,
+			want:      "I048/SP: fixed item needs a positive length",
+		},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := applyExpansion([]byte(tt.expansion))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("applyExpansion() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+	if spec.Items["SP"].Content != nil {
+		t.Errorf("SP content set by a rejected expansion")
+	}
+}
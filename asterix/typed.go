@@ -0,0 +1,653 @@
+package asterix
+
+import (
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// typedRecordTypes holds the typed record struct of each category edition
+// whose records decode straight into one instead of a map of items
+var typedRecordTypes = map[int]map[string]reflect.Type{
+	21: {"2.4": reflect.TypeOf(CAT021Record{})},
+	34: {"1.29": reflect.TypeOf(CAT034Record{})},
+	48: {"1.31": reflect.TypeOf(CAT048Record{})},
+	62: {"1.18": reflect.TypeOf(CAT062Record{})},
+}
+
+// typedLayout decodes the items of a category edition into the fields of
+// its typed record struct
+type typedLayout struct {
+	record reflect.Type
+	items  map[*Item]*typedItem
+}
+
+// typedItem decodes one item into its field of the typed record
+type typedItem struct {
+	index  int   // field of the item in the record struct
+	roles  []int // field of each role of the item, -1 for the item value itself
+	decode typedDecoder
+}
+
+// typedDecoder decodes an item from the start of data into dst, a settable
+// value of the type of its field, and returns the octets of the item. As
+// with decodeDataItem, a content error leaves the raw content in dst.
+type typedDecoder func(dst reflect.Value, data []byte) (int, error)
+
+// fieldSetter decodes a fixed layout into dst
+type fieldSetter func(dst reflect.Value, data []byte)
+
+// typedRecords returns the typed records of a data block of a category
+// edition. Records decoded into Items, such as those of a specification
+// loaded by LoadSpecDir whose items no longer fit the struct, are an error.
+func typedRecords[T any](b *Block, category int, edition string) ([]*T, error) {
+	if b.Category != category || b.Edition != edition {
+		return nil, fmt.Errorf("block is CAT %03d edition %s, not CAT %03d edition %s", b.Category, b.Edition, category, edition)
+	}
+
+	records := make([]*T, 0, len(b.Records))
+	for _, record := range b.Records {
+		switch typed := record.typed.(type) {
+		case *T:
+			records = append(records, typed)
+		case nil:
+			if len(record.Items) > 0 {
+				return nil, fmt.Errorf("record %d: %w", record.Index, untypedError(category, edition))
+			}
+			records = append(records, new(T))
+		default:
+			return nil, fmt.Errorf("record %d holds a %T", record.Index, typed)
+		}
+	}
+	return records, nil
+}
+
+// untypedError explains why a category edition decodes into Items
+func untypedError(category int, edition string) error {
+	spec := LookupSpec(category, edition)
+	if spec == nil || spec.typedErr == nil {
+		return fmt.Errorf("items were not decoded into typed records")
+	}
+	return fmt.Errorf("items were not decoded into typed records: %w", spec.typedErr)
+}
+
+// bindTyped compiles the typed layout of a category edition that has a
+// typed record struct. A specification whose items do not fit the struct,
+// such as one loaded by LoadSpecDir with a changed layout, decodes into
+// Items instead and keeps the reason in typedErr.
+func (s *Spec) bindTyped() {
+	s.typed, s.typedErr = nil, nil
+	if record := typedRecordTypes[s.Category][s.Edition]; record != nil {
+		s.typed, s.typedErr = newTypedLayout(s, record)
+	}
+}
+
+// newTypedLayout binds every item of a specification to the field of the
+// record struct with the item's name as its JSON name
+func newTypedLayout(s *Spec, record reflect.Type) (*typedLayout, error) {
+	if s.Selector != nil {
+		return nil, fmt.Errorf("CAT %03d: typed records need a single UAP", s.Category)
+	}
+
+	fields := jsonFields(record)
+	layout := &typedLayout{record: record, items: make(map[*Item]*typedItem, len(s.Items))}
+	for id, item := range s.Items {
+		index, ok := fields[item.Name]
+		if !ok {
+			return nil, fmt.Errorf("I%03d/%s: %s has no field %q", s.Category, id, record.Name(), item.Name)
+		}
+		field := record.Field(index)
+		if !isOptional(field) {
+			return nil, fmt.Errorf("I%03d/%s: field %s must be an omitempty pointer, slice or interface", s.Category, id, field.Name)
+		}
+
+		decode, err := bindItem(item, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("I%03d/%s: %w", s.Category, id, err)
+		}
+		layout.items[item] = &typedItem{index: index, roles: bindRoles(item, field.Type), decode: decode}
+	}
+	return layout, nil
+}
+
+// decode decodes an item into its field of dst, a typed record struct
+func (l *typedLayout) decode(dst reflect.Value, item *Item, data []byte) (int, error) {
+	binding := l.items[item]
+	if binding == nil {
+		return 0, fmt.Errorf("%s has no field for %s", l.record.Name(), item.Name)
+	}
+	return binding.decode(dst.Field(binding.index), data)
+}
+
+// field returns the value of an item in a typed record, with pointers and
+// interfaces resolved, and whether the record has the item
+func (l *typedLayout) field(typed interface{}, item *Item) (*typedItem, reflect.Value, bool) {
+	if l == nil || l.items[item] == nil {
+		return nil, reflect.Value{}, false
+	}
+	binding := l.items[item]
+	v := reflect.ValueOf(typed).Elem().Field(binding.index)
+	if v.IsNil() {
+		return nil, reflect.Value{}, false
+	}
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return binding, v, true
+}
+
+// float returns the value of an item of a typed record holding a single
+// scaled field
+func (l *typedLayout) float(typed interface{}, item *Item) (float64, bool) {
+	_, v, ok := l.field(typed, item)
+	if !ok || v.Kind() != reflect.Float64 {
+		return 0, false
+	}
+	return v.Float(), true
+}
+
+// addRoles adds the values of the fields with a role in an item of a typed
+// record
+func (l *typedLayout) addRoles(values Roles, typed interface{}, item *Item) {
+	binding, v, ok := l.field(typed, item)
+	if !ok {
+		return
+	}
+	if binding.roles == nil {
+		values.add(item, v.Interface())
+		return
+	}
+
+	for i, f := range item.roles {
+		fv := v
+		if index := binding.roles[i]; index >= 0 {
+			fv = v.Field(index)
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					continue // in a part that was not present
+				}
+				fv = fv.Elem()
+			}
+		}
+		values[f.Role] = append(values[f.Role], RoleValue{item, f, fv.Interface()})
+	}
+}
+
+// bindRoles returns the struct field of each role of an item held in a
+// value of type t, or nil when t is an interface holding the generic value
+func bindRoles(item *Item, t reflect.Type) []int {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(item.roles) == 0 || t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	roles := make([]int, len(item.roles))
+	for i, f := range item.roles {
+		roles[i] = -1
+		if t.Kind() == reflect.Struct {
+			roles[i] = jsonFields(t)[f.Name]
+		}
+	}
+	return roles
+}
+
+// bindItem returns the decoder of an item into a value of type t, checking
+// that t holds what decodeDataItem produces for the item
+func bindItem(item *Item, t reflect.Type) (typedDecoder, error) {
+	switch t.Kind() {
+	case reflect.Interface:
+		// Items typed as interface{}, such as RE and SP whose content comes
+		// from expansion definitions, hold the generic value
+		return func(dst reflect.Value, data []byte) (int, error) {
+			value, n, err := decodeDataItem(data, item)
+			if value != nil {
+				dst.Set(reflect.ValueOf(value))
+			}
+			return n, err
+		}, nil
+
+	case reflect.Ptr:
+		decode, err := bindItem(item, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		elem := t.Elem()
+		return func(dst reflect.Value, data []byte) (int, error) {
+			value := reflect.New(elem)
+			n, err := decode(value.Elem(), data)
+			if err == nil || isContentError(err) {
+				dst.Set(value)
+			}
+			return n, err
+		}, nil
+	}
+
+	switch item.Format {
+	case FormatFixed:
+		set, err := bindFields(item.Fields, t)
+		if err != nil {
+			return nil, err
+		}
+		return func(dst reflect.Value, data []byte) (int, error) {
+			if len(data) < item.Length {
+				return 0, truncated(item.Length, len(data))
+			}
+			set(dst, data[:item.Length])
+			return item.Length, nil
+		}, nil
+
+	case FormatExtended:
+		return bindExtended(item, t)
+
+	case FormatRepetitive:
+		return bindRepetitive(item, t)
+
+	case FormatExplicit:
+		if item.Content != nil {
+			return nil, fmt.Errorf("explicit content needs an interface field to keep undecodable content raw")
+		}
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("raw octets need a string, not %s", t)
+		}
+		return func(dst reflect.Value, data []byte) (int, error) {
+			size, err := explicitSize(data)
+			if err != nil {
+				return 0, err
+			}
+			dst.SetString(base64.StdEncoding.EncodeToString(data[1:size]))
+			return size, nil
+		}, nil
+
+	case FormatCompound:
+		return bindCompound(item, t)
+	}
+
+	return nil, fmt.Errorf("unknown format %q", item.Format)
+}
+
+// bindExtended returns the decoder of an extended item into a struct of the
+// fields of every part, or a slice with an element per part for items that
+// repeat
+func bindExtended(item *Item, t reflect.Type) (typedDecoder, error) {
+	if len(item.Parts) == 0 {
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("raw octets need a string, not %s", t)
+		}
+		return func(dst reflect.Value, data []byte) (int, error) {
+			value, n, err := decodeExtendedItem(data, item)
+			if err == nil {
+				dst.SetString(value.(string))
+			}
+			return n, err
+		}, nil
+	}
+
+	if item.Repeat {
+		if t.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("repeated parts need a slice, not %s", t)
+		}
+		parts := make([]fieldSetter, len(item.Parts))
+		for i, part := range item.Parts {
+			set, err := bindFields(part.Fields, t.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("part %d: %w", i+1, err)
+			}
+			parts[i] = set
+		}
+		return func(dst reflect.Value, data []byte) (int, error) {
+			elements := reflect.MakeSlice(t, 0, 1)
+			n, _, err := walkExtended(data, item, func(part int, chunk []byte) {
+				elements = reflect.Append(elements, reflect.Zero(t.Elem()))
+				parts[part](elements.Index(elements.Len()-1), chunk)
+			})
+			if err == nil {
+				dst.Set(elements)
+			}
+			return n, err
+		}, nil
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("parts need a struct, not %s", t)
+	}
+	names := jsonFields(t)
+	bound := make(map[int]bool)
+	parts := make([]fieldSetter, len(item.Parts))
+	for i, part := range item.Parts {
+		// Fields of the parts after the first are nil when the part is absent
+		set, err := bindFieldsInto(part.Fields, t, names, bound, i > 0)
+		if err != nil {
+			return nil, fmt.Errorf("part %d: %w", i+1, err)
+		}
+		parts[i] = set
+	}
+	extension, ok := names["extension"]
+	if !ok || t.Field(extension).Type.Kind() != reflect.String {
+		return nil, fmt.Errorf("%s has no extension string", t)
+	}
+	bound[extension] = true
+	if err := checkBound(t, bound); err != nil {
+		return nil, err
+	}
+
+	return func(dst reflect.Value, data []byte) (int, error) {
+		n, ext, err := walkExtended(data, item, func(part int, chunk []byte) {
+			parts[part](dst, chunk)
+		})
+		if err == nil && ext >= 0 {
+			dst.Field(extension).SetString(base64.StdEncoding.EncodeToString(data[ext:n]))
+		}
+		return n, err
+	}, nil
+}
+
+// bindRepetitive returns the decoder of a repetitive item into a slice of
+// elements, or a string for repeated characters. Mode S MB elements hold
+// the decoded register in their bds field.
+func bindRepetitive(item *Item, t reflect.Type) (typedDecoder, error) {
+	if item.Bulk {
+		return nil, fmt.Errorf("bulk items are only decoded into Items")
+	}
+	if item.isString() {
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("repeated characters need a string, not %s", t)
+		}
+		return func(dst reflect.Value, data []byte) (int, error) {
+			size, err := repetitiveSize(data, item)
+			if err == nil {
+				dst.SetString(strings.TrimRight(string(data[1:size]), " \x00"))
+			}
+			return size, err
+		}, nil
+	}
+
+	if t.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("repetitive items need a slice, not %s", t)
+	}
+	set, err := bindFields(item.Fields, t.Elem())
+	if err != nil {
+		return nil, err
+	}
+	bds := -1
+	if item.ModeSMB {
+		index, ok := -1, false
+		if t.Elem().Kind() == reflect.Struct {
+			index, ok = jsonFields(t.Elem())[bdsKey]
+		}
+		if !ok || t.Elem().Field(index).Type != reflect.TypeOf(&BDSRegister{}) {
+			return nil, fmt.Errorf("Mode S MB elements need a %s field of type *BDSRegister", bdsKey)
+		}
+		bds = index
+	}
+
+	return func(dst reflect.Value, data []byte) (int, error) {
+		size, err := repetitiveSize(data, item)
+		if err != nil {
+			return 0, err
+		}
+		count := int(data[0])
+		elements := reflect.MakeSlice(t, count, count)
+		for i := 0; i < count; i++ {
+			element := data[1+i*item.Length : 1+(i+1)*item.Length]
+			set(elements.Index(i), element)
+			if bds >= 0 {
+				if register := decodeBDSRegister(element[:7], int(element[7]>>4), int(element[7]&0x0f)); register != nil {
+					elements.Index(i).Field(bds).Set(reflect.ValueOf(register))
+				}
+			}
+		}
+		dst.Set(elements)
+		return size, nil
+	}, nil
+}
+
+// bindCompound returns the decoder of a compound item into a struct with a
+// field per subfield
+func bindCompound(item *Item, t reflect.Type) (typedDecoder, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("subfields need a struct, not %s", t)
+	}
+
+	type binding struct {
+		index  int
+		decode typedDecoder
+	}
+	names := jsonFields(t)
+	bound := make(map[int]bool)
+	subfields := make([]binding, len(item.Subfields))
+	for i, sub := range item.Subfields {
+		if sub == nil {
+			continue
+		}
+		index, ok := names[sub.Name]
+		if !ok {
+			return nil, fmt.Errorf("%s has no field %q", t, sub.Name)
+		}
+		if !isOptional(t.Field(index)) {
+			return nil, fmt.Errorf("subfield %s must be an omitempty pointer, slice or interface", sub.Name)
+		}
+		decode, err := bindItem(sub, t.Field(index).Type)
+		if err != nil {
+			return nil, fmt.Errorf("subfield %s: %w", sub.Name, err)
+		}
+		subfields[i] = binding{index, decode}
+		bound[index] = true
+	}
+	if err := checkBound(t, bound); err != nil {
+		return nil, err
+	}
+
+	return func(dst reflect.Value, data []byte) (int, error) {
+		return walkCompound(data, item, func(index int, data []byte) (int, error) {
+			sub := subfields[index]
+			return sub.decode(dst.Field(sub.index), data)
+		})
+	}, nil
+}
+
+// bindFields returns the setter of a fixed layout into a value of type t,
+// which holds what decodeFields produces: the raw octets of an undefined
+// layout, the single named field, or a struct of the named fields
+func bindFields(fields []*Field, t reflect.Type) (fieldSetter, error) {
+	switch {
+	case t.Kind() == reflect.Interface:
+		return func(dst reflect.Value, data []byte) {
+			dst.Set(reflect.ValueOf(decodeFields(fields, data)))
+		}, nil
+
+	case len(fields) == 0:
+		if t.Kind() != reflect.String {
+			return nil, fmt.Errorf("raw octets need a string, not %s", t)
+		}
+		return func(dst reflect.Value, data []byte) {
+			dst.SetString(base64.StdEncoding.EncodeToString(data))
+		}, nil
+
+	case isSingleField(fields):
+		bit := 0
+		for _, f := range fields {
+			if f.Type != FieldSpare {
+				return bindField(f, bit, t)
+			}
+			bit += f.Bits
+		}
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("fields need a struct, not %s", t)
+	}
+	bound := make(map[int]bool)
+	set, err := bindFieldsInto(fields, t, jsonFields(t), bound, false)
+	if err != nil {
+		return nil, err
+	}
+	return set, checkBound(t, bound)
+}
+
+// bindFieldsInto returns the setter of the named fields of a layout into the
+// fields of struct t with the same JSON names, marking each one bound.
+// Optional fields, such as those of extended parts after the first, must be
+// omitempty pointers.
+func bindFieldsInto(fields []*Field, t reflect.Type, names map[string]int, bound map[int]bool, optional bool) (fieldSetter, error) {
+	type setter struct {
+		index int
+		set   fieldSetter
+	}
+	var setters []setter
+	bit := 0
+	for _, f := range fields {
+		if f.Type != FieldSpare {
+			index, ok := names[f.Name]
+			if !ok {
+				return nil, fmt.Errorf("%s has no field %q", t, f.Name)
+			}
+			if optional && (t.Field(index).Type.Kind() != reflect.Ptr || !isOptional(t.Field(index))) {
+				return nil, fmt.Errorf("field %s of an optional part must be an omitempty pointer", f.Name)
+			}
+			set, err := bindField(f, bit, t.Field(index).Type)
+			if err != nil {
+				return nil, err
+			}
+			setters = append(setters, setter{index, set})
+			bound[index] = true
+		}
+		bit += f.Bits
+	}
+
+	return func(dst reflect.Value, data []byte) {
+		for _, s := range setters {
+			s.set(dst.Field(s.index), data)
+		}
+	}, nil
+}
+
+// bindField returns the setter of a field starting at bit into a value of
+// type t, which holds what Field.decode returns for the field
+func bindField(f *Field, bit int, t reflect.Type) (fieldSetter, error) {
+	numeric := f.Type == FieldInt || f.Type == FieldUint
+	switch {
+	case t.Kind() == reflect.Ptr:
+		set, err := bindField(f, bit, t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		elem := t.Elem()
+		return func(dst reflect.Value, data []byte) {
+			value := reflect.New(elem)
+			set(value.Elem(), data)
+			dst.Set(value)
+		}, nil
+
+	case t.Kind() == reflect.Interface:
+		return func(dst reflect.Value, data []byte) {
+			dst.Set(reflect.ValueOf(f.decode(data, bit)))
+		}, nil
+
+	case f.Type == FieldBool && t.Kind() == reflect.Bool:
+		return func(dst reflect.Value, data []byte) {
+			dst.SetBool(f.flag(data, bit))
+		}, nil
+
+	case f.isText() && t.Kind() == reflect.String:
+		return func(dst reflect.Value, data []byte) {
+			dst.SetString(f.text(data, bit))
+		}, nil
+
+	case numeric && f.scale != 0 && t.Kind() == reflect.Float64:
+		return func(dst reflect.Value, data []byte) {
+			dst.SetFloat(f.scaled(data, bit))
+		}, nil
+
+	case numeric && f.scale == 0 && t.Kind() == reflect.Int:
+		return func(dst reflect.Value, data []byte) {
+			dst.SetInt(f.integer(data, bit))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("field %s of type %s cannot be held in %s", f.Name, f.Type, t)
+}
+
+// checkBound checks that the fields of struct t that decoding does not set
+// are omitted when empty, so that the struct marshals like the decoded item
+func checkBound(t reflect.Type, bound map[int]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		if !bound[i] && !strings.HasSuffix(t.Field(i).Tag.Get("json"), ",omitempty") {
+			return fmt.Errorf("%s field %s is never decoded", t, t.Field(i).Name)
+		}
+	}
+	return nil
+}
+
+// isOptional reports whether a struct field is nil and omitted from JSON
+// when the item or part it holds is absent
+func isOptional(field reflect.StructField) bool {
+	switch field.Type.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Interface:
+		return strings.HasSuffix(field.Tag.Get("json"), ",omitempty")
+	}
+	return false
+}
+
+// jsonFields returns the index of each field of struct t by JSON name
+func jsonFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+	return fields
+}
+
+// ItemValues returns the decoded items of a record keyed by name, in the
+// form Items holds them. Typed records are converted field by field, so
+// read those through CAT021Records and the like where speed matters.
+func (r *Record) ItemValues() map[string]interface{} {
+	if r.typed == nil {
+		return r.Items
+	}
+	values, _ := genericValue(reflect.ValueOf(r.typed)).(map[string]interface{})
+	return values
+}
+
+// genericValue converts a typed value into the form decodeDataItem
+// produces: structs become maps of their fields by JSON name, leaving out
+// empty omitempty fields, and slices become lists
+func genericValue(v reflect.Value) interface{} {
+	switch v.Kind() {
+	case reflect.Ptr:
+		return genericValue(v.Elem())
+
+	case reflect.Interface:
+		return v.Interface() // already generic
+
+	case reflect.Struct:
+		t := v.Type()
+		values := make(map[string]interface{}, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			field := v.Field(i)
+			empty := field.IsZero()
+			if k := field.Kind(); k == reflect.Ptr || k == reflect.Slice || k == reflect.Interface {
+				empty = field.IsNil()
+			}
+			name, options, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if !(empty && options == "omitempty") {
+				values[name] = genericValue(field)
+			}
+		}
+		return values
+
+	case reflect.Slice:
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = genericValue(v.Index(i))
+		}
+		return values
+	}
+
+	return v.Interface()
+}
@@ -0,0 +1,139 @@
+package asterix
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// typedConverters converts blocks of the categories with typed records
+var typedConverters = map[int]struct {
+	edition string
+	record  interface{}
+	convert func(*Block) (interface{}, error)
+}{
+	21: {"2.4", CAT021Record{}, func(b *Block) (interface{}, error) { return b.CAT021Records() }},
+	34: {"1.29", CAT034Record{}, func(b *Block) (interface{}, error) { return b.CAT034Records() }},
+	48: {"1.31", CAT048Record{}, func(b *Block) (interface{}, error) { return b.CAT048Records() }},
+	62: {"1.18", CAT062Record{}, func(b *Block) (interface{}, error) { return b.CAT062Records() }},
+}
+
+// assertTypedRecords checks that a datagram of a category with typed
+// records decodes into them, and that they marshal to the same JSON as the
+// items decoded into maps
+func assertTypedRecords(t *testing.T, payload []byte) {
+	t.Helper()
+
+	typed := decodeMessage(payload, nil)
+	block := typed.Blocks[0]
+	converter, ok := typedConverters[block.Category]
+	if !ok || block.Edition != converter.edition {
+		return
+	}
+	records, err := converter.convert(block)
+	if err != nil {
+		t.Fatalf("typed records error = %v", err)
+	}
+	if n := reflect.ValueOf(records).Len(); n != len(block.Records) {
+		t.Fatalf("typed records = %d, want %d", n, len(block.Records))
+	}
+
+	spec := LookupSpec(block.Category, block.Edition)
+	layout := spec.typed
+	spec.typed = nil
+	generic := decodeMessage(payload, nil)
+	spec.typed = layout
+
+	if got, want := normaliseJSON(t, typed), normaliseJSON(t, generic); !reflect.DeepEqual(got, want) {
+		t.Errorf("typed message = %v\nwant %v", got, want)
+	}
+	for i, record := range block.Records {
+		if got, want := normaliseJSON(t, record.ItemValues()), normaliseJSON(t, generic.Blocks[0].Records[i].Items); !reflect.DeepEqual(got, want) {
+			t.Errorf("record %d ItemValues() = %v\nwant %v", i, got, want)
+		}
+	}
+}
+
+// normaliseJSON marshals a value and decodes it back into generic values
+func normaliseJSON(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	return generic
+}
+
+// Test that every typed category edition decodes into its typed record
+func TestTypedLayouts(t *testing.T) {
+	for category, converter := range typedConverters {
+		spec := LookupSpec(category, converter.edition)
+		if spec.typed == nil || spec.typed.record != reflect.TypeOf(converter.record) {
+			t.Errorf("CAT %03d edition %s has no typed layout: %v", category, converter.edition, spec.typedErr)
+		}
+	}
+}
+
+// Test typed records of a multi-record block and conversion errors
+func TestTypedRecords(t *testing.T) {
+	payload, _ := hex.DecodeString("300014" +
+		"f0" + "0102" + "3d1234" + "a0" + "40002000" + // plot
+		"81" + "10" + "0102" + "002a") // track 42
+	block := decodeMessage(payload, nil).Blocks[0]
+
+	records, err := block.CAT048Records()
+	if err != nil {
+		t.Fatalf("CAT048Records() error = %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("CAT048Records() returned %d records, want 2", len(records))
+	}
+	if p := records[0].MeasuredPositionPolar; p == nil || p.RhoNM != 64 || p.ThetaDeg != 45 {
+		t.Errorf("MeasuredPositionPolar = %+v, want 64 NM at 45 deg", p)
+	}
+	if n := records[1].TrackNumber; n == nil || *n != 42 {
+		t.Errorf("TrackNumber = %v, want 42", n)
+	}
+	if records[1].DataSourceID.SAC != 1 || records[1].TimeOfDay != nil {
+		t.Errorf("record 1 = %+v", records[1])
+	}
+	if block.Records[0].Items != nil {
+		t.Errorf("Items = %v, want nil for typed records", block.Records[0].Items)
+	}
+	assertTypedRecords(t, payload)
+
+	if _, err := block.CAT062Records(); err == nil || !strings.Contains(err.Error(), "not CAT 062") {
+		t.Errorf("CAT062Records() error = %v, want category mismatch", err)
+	}
+
+	// Records decoded into maps, such as by a specification whose layout
+	// no longer fits the typed record, are not converted
+	spec := LookupSpec(48, "1.31")
+	original := spec.Items["161"]
+	t.Cleanup(func() {
+		spec.Items["161"] = original
+		spec.bindTyped()
+	})
+	changed := *original
+	changed.Fields = []*Field{{Name: "high", Bits: 8, Type: FieldUint}, {Name: "low", Bits: 8, Type: FieldUint}}
+	spec.Items["161"] = &changed
+	spec.bindTyped()
+	if spec.typed != nil || spec.typedErr == nil {
+		t.Fatalf("typed layout = %v, want an error for a changed item", spec.typed)
+	}
+
+	block = decodeMessage(payload, nil).Blocks[0]
+	if got := block.Records[1].Items["track_number"]; !reflect.DeepEqual(got, map[string]interface{}{"high": 0, "low": 42}) {
+		t.Errorf("track_number = %#v, want the changed layout", got)
+	}
+	if _, err := block.CAT048Records(); err == nil || !strings.Contains(err.Error(), "record 0: items were not decoded into typed records") {
+		t.Errorf("CAT048Records() error = %v, want untyped records", err)
+	}
+}
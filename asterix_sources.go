@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"good-listener/asterix"
+	"gopkg.in/yaml.v3"
+)
+
+// Flags added to records whose data source does not match the registry
+const (
+	flagUnregisteredSource = "unregistered_source"
+	flagUnexpectedCategory = "unexpected_category"
+)
+
+// sourceRegistry holds the known data sources, nil when no registry is
+// configured
+var sourceRegistry *SourceRegistry
+
+// SourceRegistry describes the data sources expected on the listeners
+type SourceRegistry struct {
+	Sources []*RegisteredSource `yaml:"sources"`
+
+	bySource map[uint16]*RegisteredSource // keyed by SAC<<8 | SIC
+}
+
+// RegisteredSource describes one sensor identified by its SAC/SIC
+type RegisteredSource struct {
+	SAC            int           `yaml:"sac"`
+	SIC            int           `yaml:"sic"`
+	Name           string        `yaml:"name"`
+	Site           string        `yaml:"site,omitempty"`
+	Categories     []int         `yaml:"categories,omitempty"`      // expected categories, any when empty
+	UpdateInterval time.Duration `yaml:"update_interval,omitempty"` // expected time between updates, such as the antenna period
+
+	categories map[int]bool
+}
+
+// LoadSourceRegistry loads the data source registry used by every listener
+func LoadSourceRegistry(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("failed to read source registry: %w", err)
+	}
+
+	registry, err := parseSourceRegistry(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filename, err)
+	}
+
+	sourceRegistry = registry
+	return nil
+}
+
+// parseSourceRegistry parses and validates a YAML source registry
+func parseSourceRegistry(data []byte) (*SourceRegistry, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	var registry SourceRegistry
+	if err := decoder.Decode(&registry); err != nil {
+		return nil, fmt.Errorf("failed to parse source registry: %w", err)
+	}
+
+	registry.bySource = make(map[uint16]*RegisteredSource)
+	for i, source := range registry.Sources {
+		if source.SAC < 0 || source.SAC > 255 || source.SIC < 0 || source.SIC > 255 {
+			return nil, fmt.Errorf("source %d: invalid SAC %d SIC %d", i, source.SAC, source.SIC)
+		}
+		if source.Name == "" {
+			return nil, fmt.Errorf("source %d: name must be specified", i)
+		}
+		if source.UpdateInterval < 0 {
+			return nil, fmt.Errorf("source %d: update_interval must not be negative", i)
+		}
+
+		key := uint16(source.SAC)<<8 | uint16(source.SIC)
+		if _, exists := registry.bySource[key]; exists {
+			return nil, fmt.Errorf("source %d: SAC %d SIC %d registered twice", i, source.SAC, source.SIC)
+		}
+		registry.bySource[key] = source
+
+		if len(source.Categories) > 0 {
+			source.categories = make(map[int]bool)
+			for _, category := range source.Categories {
+				if category < 1 || category > 255 {
+					return nil, fmt.Errorf("source %d: invalid category %d", i, category)
+				}
+				source.categories[category] = true
+			}
+		}
+	}
+
+	return &registry, nil
+}
+
+// lookup returns the registered source with a SAC/SIC, or nil
+func (r *SourceRegistry) lookup(sac, sic int) *RegisteredSource {
+	if r == nil {
+		return nil
+	}
+	return r.bySource[uint16(sac)<<8|uint16(sic)]
+}
+
+// lookupRecord returns the registered source of a record's data source
+// identifier. ok is false when the record does not carry one.
+func (r *SourceRegistry) lookupRecord(values asterix.Roles) (source *RegisteredSource, ok bool) {
+	sac, okSAC := values.LastInt("sac")
+	sic, okSIC := values.LastInt("sic")
+	if !okSAC || !okSIC {
+		return nil, false
+	}
+	return r.lookup(sac, sic), true
+}
+
+// annotate names the data source of every record in its source_name
+// annotation, and lists in its flags annotation whether the record comes
+// from an unregistered source or in a category its source is not expected
+// to send
+func (r *SourceRegistry) annotate(msg *asterix.Message) {
+	for _, block := range msg.Blocks {
+		spec := asterix.LookupSpec(block.Category, block.Edition)
+		if block.Unsupported || spec == nil {
+			continue
+		}
+
+		for _, record := range block.Records {
+			source, ok := r.lookupRecord(spec.Roles(record))
+			switch {
+			case !ok:
+				continue
+			case source == nil:
+				addFlag(record, flagUnregisteredSource)
+			default:
+				annotateRecord(record, "source_name", source.Name)
+				if source.categories != nil && !source.categories[block.Category] {
+					addFlag(record, flagUnexpectedCategory)
+				}
+			}
+		}
+	}
+}
+
+// annotateRecord sets an annotation of a record
+func annotateRecord(record *asterix.Record, key string, value interface{}) {
+	if record.Annotations == nil {
+		record.Annotations = make(map[string]interface{})
+	}
+	record.Annotations[key] = value
+}
+
+// addFlag appends a flag to the flags annotation of a record
+func addFlag(record *asterix.Record, flag string) {
+	flags, _ := record.Annotations["flags"].([]string)
+	annotateRecord(record, "flags", append(flags, flag))
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"good-listener/asterix"
+)
+
+const testRegistry = `
+sources:
+  - {sac: 1, sic: 2, name: Radar North, site: NTH, categories: [34, 48], update_interval: 4s}
+  - {sac: 1, sic: 3, name: ADS-B Ground Station}
+`
+
+// Test that records name their data source and flag registry mismatches
+func TestSourceRegistryAnnotate(t *testing.T) {
+	registry, err := parseSourceRegistry([]byte(testRegistry))
+	if err != nil {
+		t.Fatalf("parseSourceRegistry() error = %v", err)
+	}
+	sourceRegistry = registry
+	defer func() { sourceRegistry = nil }()
+
+	tests := []struct {
+		name       string
+		payload    string
+		sourceName string
+		flags      []string
+	}{
+		{
+			name:       "Registered source",
+			payload:    "300009" + "c0" + "0102" + "356d4d",
+			sourceName: "Radar North",
+		},
+		{
+			name:       "Service message",
+			payload:    "220006" + "80" + "0102",
+			sourceName: "Radar North",
+		},
+		{
+			name:       "Unexpected category",
+			payload:    "150006" + "80" + "0102",
+			sourceName: "Radar North",
+			flags:      []string{flagUnexpectedCategory},
+		},
+		{
+			name:       "Any category expected",
+			payload:    "150006" + "80" + "0103",
+			sourceName: "ADS-B Ground Station",
+		},
+		{
+			name:    "Unregistered source",
+			payload: "300009" + "c0" + "0907" + "356d4d",
+			flags:   []string{flagUnregisteredSource},
+		},
+		{
+			name:    "No data source identifier",
+			payload: "300006" + "40" + "356d4d",
+		},
+	}
+
+	decoders, _ := NewDecoders(ListenerConfig{Decoders: []string{"asterix"}})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, _ := hex.DecodeString(tt.payload)
+			value, _ := decoders[0].Decode(payload, time.Time{})
+			annotations := value.(*asterix.Message).Blocks[0].Records[0].Annotations
+			if name, _ := annotations["source_name"].(string); name != tt.sourceName {
+				t.Errorf("source_name = %q, want %q", name, tt.sourceName)
+			}
+			if flags, _ := annotations["flags"].([]string); !reflect.DeepEqual(flags, tt.flags) {
+				t.Errorf("flags = %v, want %v", flags, tt.flags)
+			}
+		})
+	}
+}
+
+// Test that invalid registries are rejected
+func TestParseSourceRegistryErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry string
+		want     string
+	}{
+		{
+			name:     "Missing name",
+			registry: `{sources: [{sac: 1, sic: 2}]}`,
+			want:     "name must be specified",
+		},
+		{
+			name:     "SIC out of range",
+			registry: `{sources: [{sac: 1, sic: 256, name: a}]}`,
+			want:     "invalid SAC 1 SIC 256",
+		},
+		{
+			name:     "Duplicate source",
+			registry: `{sources: [{sac: 1, sic: 2, name: a}, {sac: 1, sic: 2, name: b}]}`,
+			want:     "SAC 1 SIC 2 registered twice",
+		},
+		{
+			name:     "Invalid category",
+			registry: `{sources: [{sac: 1, sic: 2, name: a, categories: [0]}]}`,
+			want:     "invalid category 0",
+		},
+		{
+			name:     "Unknown key",
+			registry: `{sources: [{sac: 1, sic: 2, name: a, rate: 4}]}`,
+			want:     "rate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSourceRegistry([]byte(tt.registry))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parseSourceRegistry() error = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"good-listener/asterix"
+)
+
+// Feed statistics defaults
+const (
+	DefaultStatsInterval = time.Minute      // between summaries
+	DefaultStatsGap      = 10 * time.Second // silence reported as a gap
+)
+
+// unknownSource keys the statistics of blocks and records without a data
+// source identifier
+const unknownSource = "unknown"
+
+// StatsSummary is the feed quality of every data source over one interval
+type StatsSummary struct {
+	Since    time.Time               `json:"since"`
+	Time     time.Time               `json:"time"`
+	Duration float64                 `json:"duration_s"`
+	Sources  map[string]*SourceStats `json:"sources"` // keyed by SAC/SIC, or unknown
+}
+
+// SourceStats counts the traffic of one data source over an interval
+type SourceStats struct {
+	SourceName       string      `json:"source_name,omitempty"`
+	Messages         int         `json:"messages"`   // datagrams carrying the source
+	Categories       map[int]int `json:"categories"` // datagrams per category
+	Records          int         `json:"records"`
+	RecordsPerSecond float64     `json:"records_per_s"`
+	ParseErrors      int         `json:"parse_errors"`     // datagrams with a parse error in the source's records
+	ParseErrorRate   float64     `json:"parse_error_rate"` // parse errors per datagram
+	UnknownItems     int         `json:"unknown_items"`    // records with an FRN missing from the UAP
+	Tracks           int         `json:"tracks"`
+	TrackUpdates     *TrackRates `json:"track_update_rates,omitempty"` // nil without tracks
+	Gaps             int         `json:"gaps"`                         // silences longer than the gap threshold
+	LongestGap       float64     `json:"longest_gap_s,omitempty"`
+	OpenGap          float64     `json:"open_gap_s,omitempty"` // silence past the threshold at the end of the interval
+	LastSeen         time.Time   `json:"last_seen"`
+}
+
+// TrackRates spreads the update rates of a source's tracks, naming the
+// tracks at either end so that a stale or flooding track stands out
+type TrackRates struct {
+	Min     float64 `json:"min_per_s"`
+	Mean    float64 `json:"mean_per_s"`
+	Max     float64 `json:"max_per_s"`
+	Slowest string  `json:"slowest_track"` // key of the track with the lowest rate
+	Fastest string  `json:"fastest_track"` // key of the track with the highest rate
+}
+
+// sourceCounters accumulates the statistics of one data source
+type sourceCounters struct {
+	stats        *SourceStats
+	trackUpdates map[string]int // keyed by track key
+}
+
+// sourceSeen is when a data source was last seen, kept across intervals to
+// find gaps
+type sourceSeen struct {
+	time      time.Time
+	threshold time.Duration // silence that is a gap
+	name      string        // registered name, if any
+}
+
+// feedStats accumulates per data source statistics of decoded traffic and
+// serves the latest summary over HTTP
+type feedStats struct {
+	interval time.Duration
+	gap      time.Duration
+	since    time.Time
+	sources  map[string]*sourceCounters
+	lastSeen map[string]*sourceSeen
+	last     *StatsSummary // latest completed interval
+	mu       sync.Mutex
+	server   *http.Server
+}
+
+// newFeedStats creates the statistics of a listener and starts the HTTP
+// endpoint if one is configured
+func newFeedStats(config *StatsConfig) (*feedStats, error) {
+	s := &feedStats{
+		interval: config.Interval,
+		gap:      config.GapThreshold,
+		since:    time.Now(),
+		sources:  make(map[string]*sourceCounters),
+		lastSeen: make(map[string]*sourceSeen),
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultStatsInterval
+	}
+	if s.gap <= 0 {
+		s.gap = DefaultStatsGap
+	}
+
+	if config.Listen != "" {
+		listener, err := net.Listen("tcp", config.Listen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to start stats endpoint: %w", err)
+		}
+		s.server = &http.Server{Handler: http.HandlerFunc(s.serveHTTP)}
+		go func() {
+			if err := s.server.Serve(listener); err != http.ErrServerClosed {
+				fmt.Printf("Stats endpoint error: %v\n", err)
+			}
+		}()
+	}
+
+	return s, nil
+}
+
+// serveHTTP returns the latest summary, or the current interval before the
+// first one completes
+func (s *feedStats) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mu.Lock()
+	summary := s.last
+	if summary == nil {
+		summary = s.summarise(time.Now())
+	}
+	data, err := json.Marshal(summary)
+	s.mu.Unlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
+
+// close stops the HTTP endpoint
+func (s *feedStats) close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// update counts a decoded message against the data sources of its records
+func (s *feedStats) update(msg *asterix.Message, received time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	categories := make(map[string]map[int]bool)
+	failed := make(map[string]bool)
+	registered := make(map[string]*RegisteredSource)
+	for _, block := range msg.Blocks {
+		spec := asterix.LookupSpec(block.Category, block.Edition)
+		if len(block.Records) == 0 || block.Unsupported || spec == nil {
+			if categories[unknownSource] == nil {
+				categories[unknownSource] = make(map[int]bool)
+			}
+			categories[unknownSource][block.Category] = true
+			continue
+		}
+
+		for _, record := range block.Records {
+			values := spec.Roles(record)
+			key := sourceKey(values)
+			registered[key], _ = sourceRegistry.lookupRecord(values)
+			counters := s.source(key)
+			counters.stats.Records++
+			if record.Err != nil {
+				failed[key] = true
+			}
+			var frnErr *asterix.FRNError
+			if errors.As(record.Err, &frnErr) {
+				counters.stats.UnknownItems++
+			}
+			if track, ok := trackKey(values); ok {
+				counters.trackUpdates[track]++
+			}
+
+			if categories[key] == nil {
+				categories[key] = make(map[int]bool)
+			}
+			categories[key][block.Category] = true
+		}
+	}
+
+	// Errors in the framing of the datagram count against every source in it
+	if msg.Err != nil {
+		for key := range categories {
+			failed[key] = true
+		}
+		if len(categories) == 0 {
+			categories[unknownSource] = make(map[int]bool)
+			failed[unknownSource] = true
+		}
+	}
+
+	for key, seen := range categories {
+		counters := s.source(key)
+		counters.stats.Messages++
+		for category := range seen {
+			counters.stats.Categories[category]++
+		}
+		if failed[key] {
+			counters.stats.ParseErrors++
+		}
+
+		// Registered sources expect an update every interval, so missing two
+		// in a row is a gap
+		threshold := s.gap
+		if source := registered[key]; source != nil {
+			counters.stats.SourceName = source.Name
+			if source.UpdateInterval > 0 {
+				threshold = 2 * source.UpdateInterval
+			}
+		}
+
+		seen := s.lastSeen[key]
+		if seen == nil {
+			seen = &sourceSeen{time: received}
+			s.lastSeen[key] = seen
+		}
+		if gap := received.Sub(seen.time); gap > threshold {
+			counters.stats.Gaps++
+			counters.stats.LongestGap = math.Max(counters.stats.LongestGap, roundSeconds(gap))
+		}
+		if received.After(seen.time) {
+			seen.time = received
+		}
+		seen.threshold, seen.name = threshold, counters.stats.SourceName
+		counters.stats.LastSeen = seen.time
+	}
+}
+
+// source returns the counters of a data source, creating them if needed
+func (s *feedStats) source(key string) *sourceCounters {
+	counters := s.sources[key]
+	if counters == nil {
+		counters = &sourceCounters{
+			stats:        &SourceStats{Categories: make(map[int]int)},
+			trackUpdates: make(map[string]int),
+		}
+		s.sources[key] = counters
+	}
+	return counters
+}
+
+// sourceKey returns the SAC/SIC of a record, or unknownSource without one
+func sourceKey(values asterix.Roles) string {
+	sac, okSAC := values.LastInt("sac")
+	sic, okSIC := values.LastInt("sic")
+	if !okSAC || !okSIC {
+		return unknownSource
+	}
+	return fmt.Sprintf("%d/%d", sac, sic)
+}
+
+// summary completes the current interval and starts the next
+func (s *feedStats) summary(now time.Time) *StatsSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.last = s.summarise(now)
+	s.since = now
+	s.sources = make(map[string]*sourceCounters)
+	return s.last
+}
+
+// summarise computes the rates of the current interval
+func (s *feedStats) summarise(now time.Time) *StatsSummary {
+	duration := now.Sub(s.since).Seconds()
+	summary := &StatsSummary{
+		Since:    s.since.UTC(),
+		Time:     now.UTC(),
+		Duration: roundSeconds(now.Sub(s.since)),
+		Sources:  make(map[string]*SourceStats, len(s.sources)),
+	}
+
+	for key, counters := range s.sources {
+		stats := *counters.stats
+		stats.Categories = make(map[int]int, len(counters.stats.Categories))
+		for category, n := range counters.stats.Categories {
+			stats.Categories[category] = n
+		}
+		stats.LastSeen = stats.LastSeen.UTC()
+
+		if stats.Messages > 0 {
+			stats.ParseErrorRate = math.Round(float64(stats.ParseErrors)/float64(stats.Messages)*1000) / 1000
+		}
+		stats.Tracks = len(counters.trackUpdates)
+		if duration > 0 {
+			stats.RecordsPerSecond = math.Round(float64(stats.Records)/duration*1000) / 1000
+			stats.TrackUpdates = trackRates(counters.trackUpdates, duration)
+		}
+		summary.Sources[key] = &stats
+	}
+
+	// A source silent past its threshold is in a gap that has not closed yet,
+	// whether or not it was seen earlier in the interval
+	for key, seen := range s.lastSeen {
+		silence := now.Sub(seen.time)
+		if silence <= seen.threshold {
+			continue
+		}
+		stats := summary.Sources[key]
+		if stats == nil {
+			stats = &SourceStats{
+				SourceName: seen.name,
+				Categories: make(map[int]int),
+				LastSeen:   seen.time.UTC(),
+			}
+			summary.Sources[key] = stats
+		}
+		stats.OpenGap = roundSeconds(silence)
+	}
+
+	return summary
+}
+
+// trackRates returns the spread of the update rates of tracks over an
+// interval, or nil without tracks. Ties go to the first key in order.
+func trackRates(updates map[string]int, duration float64) *TrackRates {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	slowest, fastest, total := keys[0], keys[0], 0
+	for _, key := range keys {
+		if updates[key] < updates[slowest] {
+			slowest = key
+		}
+		if updates[key] > updates[fastest] {
+			fastest = key
+		}
+		total += updates[key]
+	}
+
+	rate := func(n float64) float64 {
+		return math.Round(n/duration*1000) / 1000
+	}
+	return &TrackRates{
+		Min:     rate(float64(updates[slowest])),
+		Mean:    rate(float64(total) / float64(len(keys))),
+		Max:     rate(float64(updates[fastest])),
+		Slowest: slowest,
+		Fastest: fastest,
+	}
+}
+
+// roundSeconds returns a duration in seconds rounded to milliseconds
+func roundSeconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
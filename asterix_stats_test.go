@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test the feed statistics of each data source over an interval
+func TestFeedStats(t *testing.T) {
+	decoders, err := NewDecoders(ListenerConfig{
+		Decoders: []string{"asterix"},
+		Asterix:  &AsterixConfig{Stats: &StatsConfig{Interval: time.Minute}},
+	})
+	if err != nil {
+		t.Fatalf("NewDecoders() error = %v", err)
+	}
+	decoder := decoders[0].(*asterixDecoder)
+	defer decoder.Close()
+
+	// CAT 048 track 42 from SAC 1 SIC 2
+	track := "30000b" + "85" + "10" + "0102" + "0578" + "002a"
+	messages := []struct {
+		offset  time.Duration
+		payload string
+	}{
+		{0, track},
+		{time.Second, track + "30000b" + "85" + "10" + "0102" + "0578" + "002b"}, // and track 43
+		{20 * time.Second, track},                                                          // after a gap
+		{21 * time.Second, "30000a" + "8101010180" + "0102"},                               // FRN 29 is not in the UAP
+		{22 * time.Second, "c80006" + "010203"},                                            // unsupported category
+		{23 * time.Second, "30000a" + "90" + "0103" + "40002000" + "ff"},                   // trailing octet from SAC 1 SIC 3
+		{24 * time.Second, "30000f" + "90" + "0103" + "40002000" + "90" + "0103" + "4000"}, // truncated record
+	}
+
+	start := time.Date(2025, 11, 27, 7, 35, 0, 0, time.UTC)
+	decoder.stats.since = start
+	for _, m := range messages {
+		payload, _ := hex.DecodeString(m.payload)
+		decoder.Decode(payload, start.Add(m.offset))
+	}
+
+	// Until the first interval completes the endpoint shows the current one
+	recorder := httptest.NewRecorder()
+	decoder.stats.serveHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("serveHTTP() status = %d", recorder.Code)
+	}
+
+	summary := decoder.Summary(start.Add(time.Minute)).(*StatsSummary)
+	expected := &StatsSummary{
+		Since:    start,
+		Time:     start.Add(time.Minute),
+		Duration: 60,
+		Sources: map[string]*SourceStats{
+			"1/2": {
+				Messages:         4,
+				Categories:       map[int]int{48: 4},
+				Records:          5,
+				RecordsPerSecond: 0.083,
+				ParseErrors:      1,
+				ParseErrorRate:   0.25,
+				UnknownItems:     1,
+				Tracks:           2,
+				TrackUpdates: &TrackRates{
+					Min: 0.017, Mean: 0.033, Max: 0.05, Slowest: "1/2/T43", Fastest: "1/2/T42",
+				},
+				Gaps:       1,
+				LongestGap: 19,
+				OpenGap:    39,
+				LastSeen:   start.Add(21 * time.Second),
+			},
+			"1/3": {
+				Messages:         2,
+				Categories:       map[int]int{48: 2},
+				Records:          3,
+				RecordsPerSecond: 0.05,
+				ParseErrors:      2,
+				ParseErrorRate:   1,
+				OpenGap:          36,
+				LastSeen:         start.Add(24 * time.Second),
+			},
+			"unknown": {
+				Messages:   1,
+				Categories: map[int]int{200: 1},
+				OpenGap:    38,
+				LastSeen:   start.Add(22 * time.Second),
+			},
+		},
+	}
+	for key, stats := range expected.Sources {
+		if !reflect.DeepEqual(summary.Sources[key], stats) {
+			t.Errorf("source %s = %+v\nwant %+v", key, summary.Sources[key], stats)
+		}
+	}
+	if len(summary.Sources) != len(expected.Sources) || summary.Duration != expected.Duration || !summary.Since.Equal(start) {
+		t.Errorf("summary = %+v, want %+v", summary, expected)
+	}
+
+	// The endpoint then serves the completed interval while the next starts
+	// with only the open gaps of the sources that went silent
+	recorder = httptest.NewRecorder()
+	decoder.stats.serveHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	var served StatsSummary
+	if err := json.Unmarshal(recorder.Body.Bytes(), &served); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if served.Sources["1/2"].Records != 5 {
+		t.Errorf("served records = %d, want 5", served.Sources["1/2"].Records)
+	}
+	next := decoder.Summary(start.Add(2 * time.Minute)).(*StatsSummary)
+	dark := &SourceStats{Categories: map[int]int{}, OpenGap: 99, LastSeen: start.Add(21 * time.Second)}
+	if len(next.Sources) != 3 || !reflect.DeepEqual(next.Sources["1/2"], dark) {
+		t.Errorf("next interval source 1/2 = %+v of %d sources, want %+v", next.Sources["1/2"], len(next.Sources), dark)
+	}
+}
+
+// Test that registered sources name their statistics and set their gap threshold
+func TestFeedStatsRegisteredSource(t *testing.T) {
+	registry, err := parseSourceRegistry([]byte(testRegistry))
+	if err != nil {
+		t.Fatalf("parseSourceRegistry() error = %v", err)
+	}
+	sourceRegistry = registry
+	defer func() { sourceRegistry = nil }()
+
+	decoders, _ := NewDecoders(ListenerConfig{
+		Decoders: []string{"asterix"},
+		Asterix:  &AsterixConfig{Stats: &StatsConfig{}},
+	})
+	decoder := decoders[0].(*asterixDecoder)
+	defer decoder.Close()
+
+	// Radar North updates every 4s, so 9s of silence is a gap for it but
+	// not for the unregistered source under the default threshold
+	start := time.Date(2025, 11, 27, 7, 35, 0, 0, time.UTC)
+	for _, offset := range []time.Duration{0, 9 * time.Second} {
+		for _, source := range []string{"0102", "0907"} {
+			payload, _ := hex.DecodeString("300006" + "80" + source)
+			decoder.Decode(payload, start.Add(offset))
+		}
+	}
+
+	summary := decoder.Summary(start.Add(time.Minute)).(*StatsSummary)
+	if stats := summary.Sources["1/2"]; stats.SourceName != "Radar North" || stats.Gaps != 1 {
+		t.Errorf("source 1/2 = %+v, want Radar North with 1 gap", stats)
+	}
+	if stats := summary.Sources["9/7"]; stats.SourceName != "" || stats.Gaps != 0 {
+		t.Errorf("source 9/7 = %+v, want no name and no gaps", stats)
+	}
+}
+
+// Test that a DATA listener counts its traffic although it logs no decoded
+// entries
+func TestFeedStatsDataListener(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "data.log")
+	logger, err := NewListenerLogger(ListenerConfig{
+		LogFile:  logFile,
+		LogLevel: LogLevelData,
+		Decoders: []string{"asterix"},
+		Asterix:  &AsterixConfig{Stats: &StatsConfig{}},
+	})
+	if err != nil {
+		t.Fatalf("NewListenerLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	payload, _ := hex.DecodeString("300006" + "80" + "0102")
+	for i := 0; i < 2; i++ {
+		if err := logger.LogData("127.0.0.1", 8600, "udp", payload); err != nil {
+			t.Fatalf("LogData() error = %v", err)
+		}
+	}
+
+	decoder := logger.decoders[0].(*asterixDecoder)
+	summary := decoder.Summary(time.Now()).(*StatsSummary)
+	if stats := summary.Sources["1/2"]; stats == nil || stats.Messages != 2 {
+		t.Errorf("source 1/2 = %+v, want 2 messages", stats)
+	}
+	data, _ := os.ReadFile(logFile)
+	if want := string(payload) + "\n" + string(payload) + "\n"; string(data) != want {
+		t.Errorf("log = %q, want the raw payloads %q", data, want)
+	}
+}
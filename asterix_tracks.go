@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"good-listener/asterix"
+)
+
+// Track table defaults
+const (
+	DefaultTrackInterval = 10 * time.Second // between snapshots
+	DefaultTrackMaxAge   = time.Minute      // before a silent track is dropped
+)
+
+// Track is the latest state of one target, accumulated from the records
+// that carry its data source and track number or target address
+type Track struct {
+	Key             string             `json:"key"` // SAC/SIC followed by T and the track number or A and the address
+	SAC             int                `json:"sac"`
+	SIC             int                `json:"sic"`
+	Category        int                `json:"category"` // category of the latest record
+	TrackNumber     *int               `json:"track_number,omitempty"`
+	TargetAddress   string             `json:"target_address,omitempty"`
+	Callsign        string             `json:"callsign,omitempty"`
+	Mode3A          string             `json:"mode3a,omitempty"`
+	FlightLevel     *float64           `json:"flight_level,omitempty"`
+	GeometricHeight *float64           `json:"geometric_height_ft,omitempty"`
+	Latitude        *float64           `json:"latitude,omitempty"`
+	Longitude       *float64           `json:"longitude,omitempty"`
+	LocalPosition   map[string]float64 `json:"local_position,omitempty"` // position relative to the sensor, keyed by field name
+	FirstSeen       time.Time          `json:"first_seen"`
+	LastSeen        time.Time          `json:"last_seen"`
+	Updates         int                `json:"updates"`
+}
+
+// TrackSnapshot is the content of the track snapshot file
+type TrackSnapshot struct {
+	Time   time.Time `json:"time"`
+	Tracks []*Track  `json:"tracks"` // sorted by key
+}
+
+// trackTable accumulates the tracks seen by a listener and periodically
+// writes them to a snapshot file
+type trackTable struct {
+	filename string
+	maxAge   time.Duration
+	tracks   map[string]*Track
+	mu       sync.Mutex
+	ticker   *time.Ticker
+	stopChan chan struct{}
+}
+
+// newTrackTable creates a track table and starts writing snapshots
+func newTrackTable(config *TrackConfig) *trackTable {
+	interval, maxAge := config.Interval, config.MaxAge
+	if interval <= 0 {
+		interval = DefaultTrackInterval
+	}
+	if maxAge <= 0 {
+		maxAge = DefaultTrackMaxAge
+	}
+
+	table := &trackTable{
+		filename: config.SnapshotFile,
+		maxAge:   maxAge,
+		tracks:   make(map[string]*Track),
+		ticker:   time.NewTicker(interval),
+		stopChan: make(chan struct{}),
+	}
+	go table.run()
+
+	return table
+}
+
+// run writes a snapshot on every tick until the table is closed
+func (tt *trackTable) run() {
+	for {
+		select {
+		case <-tt.ticker.C:
+			if err := tt.writeSnapshot(time.Now()); err != nil {
+				fmt.Printf("Failed to write track snapshot: %v\n", err)
+			}
+		case <-tt.stopChan:
+			return
+		}
+	}
+}
+
+// close stops the snapshots after writing a final one
+func (tt *trackTable) close() error {
+	close(tt.stopChan)
+	tt.ticker.Stop()
+	return tt.writeSnapshot(time.Now())
+}
+
+// update applies every record of a message that identifies a target
+func (tt *trackTable) update(msg *asterix.Message, received time.Time) {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+
+	for _, block := range msg.Blocks {
+		spec := asterix.LookupSpec(block.Category, block.Edition)
+		if block.Unsupported || spec == nil {
+			continue
+		}
+
+		for _, record := range block.Records {
+			values := spec.Roles(record)
+			key, ok := trackKey(values)
+			if !ok {
+				continue
+			}
+
+			track := tt.tracks[key]
+			if track == nil {
+				track = &Track{Key: key, FirstSeen: received}
+				tt.tracks[key] = track
+			}
+			track.Category = block.Category
+			track.LastSeen = received
+			track.Updates++
+			track.apply(values)
+		}
+	}
+}
+
+// trackKey builds the key of the target a record describes, preferring the
+// track number over the target address
+func trackKey(values asterix.Roles) (string, bool) {
+	sac, okSAC := values.LastInt("sac")
+	sic, okSIC := values.LastInt("sic")
+	if !okSAC || !okSIC {
+		return "", false
+	}
+
+	if number, ok := values.LastInt("track_number"); ok {
+		return fmt.Sprintf("%d/%d/T%d", sac, sic, number), true
+	}
+	if address, ok := values.LastString("target_address"); ok {
+		return fmt.Sprintf("%d/%d/A%s", sac, sic, address), true
+	}
+	return "", false
+}
+
+// apply copies the latest value of each role into the track
+func (t *Track) apply(values asterix.Roles) {
+	t.SAC, _ = values.LastInt("sac")
+	t.SIC, _ = values.LastInt("sic")
+	if v, ok := values.LastInt("track_number"); ok {
+		t.TrackNumber = &v
+	}
+	if v, ok := values.LastString("target_address"); ok {
+		t.TargetAddress = v
+	}
+	if v, ok := values.LastString("callsign"); ok && v != "" {
+		t.Callsign = v
+	}
+	if v, ok := values.LastString("mode3a"); ok {
+		t.Mode3A = v
+	}
+	if v, ok := values.LastFloat("flight_level"); ok {
+		t.FlightLevel = &v
+	}
+	if v, ok := values.LastFloat("geometric_height"); ok {
+		t.GeometricHeight = &v
+	}
+
+	lat, okLat := values.LastFloat("latitude")
+	lon, okLon := values.LastFloat("longitude")
+	if okLat && okLon {
+		t.Latitude, t.Longitude = &lat, &lon
+	}
+
+	// The last position item replaces the previous local position whole,
+	// since polar and Cartesian fields cannot be mixed
+	if local := values["local_position"]; len(local) > 0 {
+		t.LocalPosition = make(map[string]float64)
+		item := local[len(local)-1].Item
+		for _, rv := range local {
+			if v, ok := rv.Float(); ok && rv.Item == item {
+				t.LocalPosition[rv.Field.Name] = v
+			}
+		}
+	}
+}
+
+// expire drops the tracks not updated within the maximum age
+func (tt *trackTable) expire(now time.Time) {
+	for key, track := range tt.tracks {
+		if now.Sub(track.LastSeen) > tt.maxAge {
+			delete(tt.tracks, key)
+		}
+	}
+}
+
+// writeSnapshot drops stale tracks and replaces the snapshot file with the
+// remaining ones
+func (tt *trackTable) writeSnapshot(now time.Time) error {
+	tt.mu.Lock()
+	tt.expire(now)
+	snapshot := TrackSnapshot{Time: now.UTC(), Tracks: make([]*Track, 0, len(tt.tracks))}
+	for _, track := range tt.tracks {
+		snapshot.Tracks = append(snapshot.Tracks, track)
+	}
+	sort.Slice(snapshot.Tracks, func(i, j int) bool { return snapshot.Tracks[i].Key < snapshot.Tracks[j].Key })
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	tt.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	// Write a temporary file and rename it so readers never see a partial
+	// snapshot
+	if err := os.MkdirAll(filepath.Dir(tt.filename), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp := tt.filename + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, tt.filename); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Test that the track table accumulates target state across messages
+func TestTrackTable(t *testing.T) {
+	snapshotFile := filepath.Join(t.TempDir(), "tracks.json")
+	decoders, err := NewDecoders(ListenerConfig{
+		Decoders: []string{"asterix"},
+		Asterix:  &AsterixConfig{Tracks: &TrackConfig{SnapshotFile: snapshotFile, Interval: time.Hour}},
+	})
+	if err != nil {
+		t.Fatalf("NewDecoders() error = %v", err)
+	}
+	decoder := decoders[0].(*asterixDecoder)
+	defer decoder.Close()
+
+	messages := []string{
+		// CAT 048 track 42 with position, Mode 3/A, flight level, address and callsign
+		"30001a" +
+			"9d" + "d0" + // FSPEC: FRN 1, 4, 5, 6, 8, 9 and 11
+			"0102" + // I048/010: SAC=1 SIC=2
+			"40002000" + // I048/040: 64 NM at 45 deg
+			"0e00" + // I048/070: 7000
+			"0578" + // I048/090: FL 350
+			"4ca123" + // I048/220
+			"0494b1cb3820" + // I048/240: AIR123
+			"002a", // I048/161: track 42
+		// CAT 048 track 42 climbing, without the other items
+		"30000b" +
+			"85" + "10" + // FSPEC: FRN 1, 6 and 11
+			"0102" + // I048/010: SAC=1 SIC=2
+			"05a0" + // I048/090: FL 360
+			"002a", // I048/161: track 42
+		// CAT 021 report without a track number, keyed by the target address
+		"150013" +
+			"85" + "11" + "40" + // FSPEC: FRN 1, 6, 11 and 16
+			"0103" + // I021/010: SAC=1 SIC=3
+			"200000c00000" + // I021/130: 45 deg N 90 deg W
+			"4ca123" + // I021/080
+			"15e0", // I021/140: 35000 ft
+		// CAT 048 plot with no track number or address is not a track
+		"300009" +
+			"90" + // FSPEC: FRN 1 and 4
+			"0102" + // I048/010: SAC=1 SIC=2
+			"40002000", // I048/040
+	}
+
+	start := time.Date(2025, 11, 27, 7, 35, 55, 0, time.UTC)
+	for i, message := range messages {
+		payload, _ := hex.DecodeString(message)
+		if _, err := decoder.Decode(payload, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Decode() message %d error = %v", i, err)
+		}
+	}
+
+	if err := decoder.tracks.writeSnapshot(start.Add(30 * time.Second)); err != nil {
+		t.Fatalf("writeSnapshot() error = %v", err)
+	}
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var snapshot map[string]interface{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"time": "2025-11-27T07:36:25Z",
+		"tracks": []interface{}{
+			map[string]interface{}{
+				"key":            "1/2/T42",
+				"sac":            1.0,
+				"sic":            2.0,
+				"category":       48.0,
+				"track_number":   42.0,
+				"target_address": "4CA123",
+				"callsign":       "AIR123",
+				"mode3a":         "7000",
+				"flight_level":   360.0,
+				"local_position": map[string]interface{}{"rho_nm": 64.0, "theta_deg": 45.0},
+				"first_seen":     "2025-11-27T07:35:55Z",
+				"last_seen":      "2025-11-27T07:35:56Z",
+				"updates":        2.0,
+			},
+			map[string]interface{}{
+				"key":                 "1/3/A4CA123",
+				"sac":                 1.0,
+				"sic":                 3.0,
+				"category":            21.0,
+				"target_address":      "4CA123",
+				"geometric_height_ft": 35000.0,
+				"latitude":            45.0,
+				"longitude":           -90.0,
+				"first_seen":          "2025-11-27T07:35:57Z",
+				"last_seen":           "2025-11-27T07:35:57Z",
+				"updates":             1.0,
+			},
+		},
+	}
+	if !reflect.DeepEqual(snapshot, expected) {
+		t.Errorf("snapshot = %s", data)
+	}
+
+	// Both tracks are older than the default maximum age a minute later
+	if err := decoder.tracks.writeSnapshot(start.Add(2 * time.Minute)); err != nil {
+		t.Fatalf("writeSnapshot() error = %v", err)
+	}
+	data, _ = os.ReadFile(snapshotFile)
+	var aged TrackSnapshot
+	if err := json.Unmarshal(data, &aged); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(aged.Tracks) != 0 {
+		t.Errorf("snapshot has %d tracks after max_age, want 0", len(aged.Tracks))
+	}
+}
+
+// Test that a DATA listener keeps its track table although it logs no
+// decoded entries
+func TestTrackTableDataListener(t *testing.T) {
+	dir := t.TempDir()
+	snapshotFile := filepath.Join(dir, "tracks.json")
+	logger, err := NewListenerLogger(ListenerConfig{
+		LogFile:  filepath.Join(dir, "data.log"),
+		LogLevel: LogLevelData,
+		Decoders: []string{"asterix"},
+		Asterix:  &AsterixConfig{Tracks: &TrackConfig{SnapshotFile: snapshotFile, Interval: time.Hour}},
+	})
+	if err != nil {
+		t.Fatalf("NewListenerLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	// CAT 048 track 42 from SAC 1 SIC 2
+	payload, _ := hex.DecodeString("30000b" + "85" + "10" + "0102" + "0578" + "002a")
+	if err := logger.LogData("127.0.0.1", 8600, "udp", payload); err != nil {
+		t.Fatalf("LogData() error = %v", err)
+	}
+
+	decoder := logger.decoders[0].(*asterixDecoder)
+	if err := decoder.tracks.writeSnapshot(time.Now()); err != nil {
+		t.Fatalf("writeSnapshot() error = %v", err)
+	}
+	data, err := os.ReadFile(snapshotFile)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var snapshot TrackSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(snapshot.Tracks) != 1 || snapshot.Tracks[0].Key != "1/2/T42" {
+		t.Errorf("snapshot = %s, want track 1/2/T42", data)
+	}
+}
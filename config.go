@@ -2,7 +2,10 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -39,14 +42,50 @@ type ListenerConfig struct {
 	LogFile        string         `yaml:"log_file"`
 	LogLevel       LogLevel       `yaml:"log_level"`
 	BinaryEncoding BinaryEncoding `yaml:"binary_encoding,omitempty"` // "base64" or "hex", defaults to "base64"
+	Decoders       []string       `yaml:"decoders,omitempty"`        // payload decoders to try, defaults to DefaultDecoders
+	Asterix        *AsterixConfig `yaml:"asterix,omitempty"`         // ASTERIX decoder options
 	// TLS-specific configuration
 	TLSCertFile string `yaml:"tls_cert_file,omitempty"`
 	TLSKeyFile  string `yaml:"tls_key_file,omitempty"`
 }
 
+// AsterixConfig holds the ASTERIX decoder options for a listener
+type AsterixConfig struct {
+	VideoFile  string                `yaml:"video_file,omitempty"` // names the side files receiving bulk items such as CAT 240 video blocks
+	Editions   map[int]string        `yaml:"editions,omitempty"`   // category to edition, defaults to the latest loaded
+	Sources    []AsterixSourceConfig `yaml:"sources,omitempty"`    // per data source edition overrides
+	Categories []int                 `yaml:"categories,omitempty"` // categories accepted by detection, defaults to all
+	Tracks     *TrackConfig          `yaml:"tracks,omitempty"`     // track table, disabled when nil
+	Stats      *StatsConfig          `yaml:"stats,omitempty"`      // feed statistics, disabled when nil
+}
+
+// TrackConfig enables the track table of a listener
+type TrackConfig struct {
+	SnapshotFile string        `yaml:"snapshot_file"`
+	Interval     time.Duration `yaml:"interval,omitempty"` // between snapshots, defaults to DefaultTrackInterval
+	MaxAge       time.Duration `yaml:"max_age,omitempty"`  // drop tracks not updated for this long, defaults to DefaultTrackMaxAge
+}
+
+// StatsConfig enables the feed statistics of a listener
+type StatsConfig struct {
+	Interval     time.Duration `yaml:"interval,omitempty"`      // between summaries, defaults to DefaultStatsInterval
+	GapThreshold time.Duration `yaml:"gap_threshold,omitempty"` // silence counted as a gap, defaults to DefaultStatsGap
+	Listen       string        `yaml:"listen,omitempty"`        // address of the HTTP endpoint, such as 127.0.0.1:8700
+}
+
+// AsterixSourceConfig overrides the category editions for one data source
+type AsterixSourceConfig struct {
+	SAC      int            `yaml:"sac"`
+	SIC      int            `yaml:"sic"`
+	Editions map[int]string `yaml:"editions"` // category to edition
+}
+
 // Config represents the overall configuration
 type Config struct {
-	Listeners []ListenerConfig `yaml:"listeners"`
+	AsterixSpecDir      string           `yaml:"asterix_spec_dir,omitempty"`      // directory of additional ASTERIX category specifications
+	AsterixExpansionDir string           `yaml:"asterix_expansion_dir,omitempty"` // directory of RE and SP field definitions
+	SourceRegistry      string           `yaml:"source_registry,omitempty"`       // file describing the known SAC/SIC data sources
+	Listeners           []ListenerConfig `yaml:"listeners"`
 }
 
 // LoadConfig loads and parses the configuration file
@@ -99,6 +138,18 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("listener %d: invalid binary_encoding %s (must be base64 or hex)", i, listener.BinaryEncoding)
 		}
 
+		for _, name := range listener.Decoders {
+			if _, ok := decoderRegistry[name]; !ok {
+				return fmt.Errorf("listener %d: unknown decoder %s (available: %s)", i, name, strings.Join(RegisteredDecoders(), ", "))
+			}
+		}
+
+		if listener.Asterix != nil {
+			if err := listener.Asterix.Validate(); err != nil {
+				return fmt.Errorf("listener %d: asterix: %w", i, err)
+			}
+		}
+
 		if listener.Protocol == ProtocolTLS {
 			if listener.TLSCertFile == "" || listener.TLSKeyFile == "" {
 				return fmt.Errorf("listener %d: TLS protocol requires tls_cert_file and tls_key_file", i)
@@ -108,3 +159,70 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// Validate checks the ASTERIX options. Editions are checked against the
+// loaded specifications when the decoder is created.
+func (a *AsterixConfig) Validate() error {
+	if err := validateEditions(a.Editions); err != nil {
+		return err
+	}
+
+	for _, category := range a.Categories {
+		if category < 1 || category > 255 {
+			return fmt.Errorf("invalid category %d", category)
+		}
+	}
+
+	if a.Tracks != nil {
+		if a.Tracks.SnapshotFile == "" {
+			return fmt.Errorf("tracks: snapshot_file must be specified")
+		}
+		if a.Tracks.Interval < 0 || a.Tracks.MaxAge < 0 {
+			return fmt.Errorf("tracks: interval and max_age must not be negative")
+		}
+	}
+
+	if a.Stats != nil {
+		if a.Stats.Interval < 0 || a.Stats.GapThreshold < 0 {
+			return fmt.Errorf("stats: interval and gap_threshold must not be negative")
+		}
+		if a.Stats.Listen != "" {
+			if _, _, err := net.SplitHostPort(a.Stats.Listen); err != nil {
+				return fmt.Errorf("stats: invalid listen address: %w", err)
+			}
+		}
+	}
+
+	seen := make(map[[2]int]bool)
+	for i, source := range a.Sources {
+		if source.SAC < 0 || source.SAC > 255 || source.SIC < 0 || source.SIC > 255 {
+			return fmt.Errorf("source %d: invalid SAC/SIC %d/%d", i, source.SAC, source.SIC)
+		}
+		if seen[[2]int{source.SAC, source.SIC}] {
+			return fmt.Errorf("source %d: SAC %d SIC %d is listed more than once", i, source.SAC, source.SIC)
+		}
+		seen[[2]int{source.SAC, source.SIC}] = true
+
+		if len(source.Editions) == 0 {
+			return fmt.Errorf("source %d: editions must be specified", i)
+		}
+		if err := validateEditions(source.Editions); err != nil {
+			return fmt.Errorf("source %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validateEditions checks a category to edition map
+func validateEditions(editions map[int]string) error {
+	for category, edition := range editions {
+		if category < 1 || category > 255 {
+			return fmt.Errorf("invalid category %d", category)
+		}
+		if edition == "" {
+			return fmt.Errorf("CAT %03d: empty edition", category)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Decoder detects and decodes a structured payload format. Decoders that
+// hold files or goroutines also implement io.Closer and are closed with the
+// listener's logger.
+type Decoder interface {
+	// Name returns the key used for the decoder in configuration and log entries
+	Name() string
+	// Detect returns the confidence, from 0 to 1, that the payload is in this
+	// decoder's format. Payloads scoring 0 are not decoded.
+	Detect(payload []byte) float64
+	// Decode decodes the payload into a JSON-marshalable value. received is
+	// when the payload arrived, or the zero time if unknown.
+	Decode(payload []byte, received time.Time) (interface{}, error)
+}
+
+// Summarizer is implemented by decoders that report periodic summaries, such
+// as feed statistics. The logger calls Summary every SummaryInterval and
+// writes the result as a summary entry; an interval of 0 disables it.
+type Summarizer interface {
+	SummaryInterval() time.Duration
+	Summary(now time.Time) interface{}
+}
+
+// Monitor is implemented by decoders that keep state across payloads, such as
+// a track table or feed statistics. While Monitoring returns true the logger
+// decodes every payload the decoder detects at every log level, discarding
+// the value on DATA listeners.
+type Monitor interface {
+	Monitoring() bool
+}
+
+// DecoderFactory creates a decoder instance for a listener
+type DecoderFactory func(config ListenerConfig) (Decoder, error)
+
+// DefaultDecoders lists the decoders used when a listener does not configure any
+var DefaultDecoders = []string{"asterix"}
+
+var decoderRegistry = make(map[string]DecoderFactory)
+
+// RegisterDecoder makes a decoder available to listeners under the given name
+func RegisterDecoder(name string, factory DecoderFactory) {
+	if _, exists := decoderRegistry[name]; exists {
+		panic(fmt.Sprintf("decoder %q registered twice", name))
+	}
+	decoderRegistry[name] = factory
+}
+
+// RegisteredDecoders returns the names of all registered decoders, sorted
+func RegisteredDecoders() []string {
+	names := make([]string, 0, len(decoderRegistry))
+	for name := range decoderRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewDecoders creates the decoders configured for a listener. A nil decoder
+// list selects DefaultDecoders; an empty list disables decoding.
+func NewDecoders(config ListenerConfig) ([]Decoder, error) {
+	names := config.Decoders
+	if names == nil {
+		names = DefaultDecoders
+	}
+
+	decoders := make([]Decoder, 0, len(names))
+	for _, name := range names {
+		factory, ok := decoderRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown decoder %q", name)
+		}
+
+		decoder, err := factory(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s decoder: %w", name, err)
+		}
+		decoders = append(decoders, decoder)
+	}
+
+	return decoders, nil
+}
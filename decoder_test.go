@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test decoder selection from listener configuration
+func TestNewDecoders(t *testing.T) {
+	tests := []struct {
+		name     string
+		decoders []string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "Defaults when not configured",
+			decoders: nil,
+			expected: DefaultDecoders,
+		},
+		{
+			name:     "Explicit empty list disables decoding",
+			decoders: []string{},
+			expected: []string{},
+		},
+		{
+			name:     "Explicit ASTERIX",
+			decoders: []string{"asterix"},
+			expected: []string{"asterix"},
+		},
+		{
+			name:     "Unknown decoder",
+			decoders: []string{"nonesuch"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decoders, err := NewDecoders(ListenerConfig{Decoders: tt.decoders})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDecoders() error = %v", err)
+			}
+
+			if len(decoders) != len(tt.expected) {
+				t.Fatalf("got %d decoders, want %d", len(decoders), len(tt.expected))
+			}
+			for i, decoder := range decoders {
+				if decoder.Name() != tt.expected[i] {
+					t.Errorf("decoder %d = %s, want %s", i, decoder.Name(), tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// panicDecoder panics on every payload it claims
+type panicDecoder struct{}
+
+func (panicDecoder) Name() string                  { return "panic" }
+func (panicDecoder) Detect(payload []byte) float64 { return 1 }
+func (panicDecoder) Decode(payload []byte, received time.Time) (interface{}, error) {
+	var items map[string]int
+	items["crash"]++
+	return nil, nil
+}
+
+// Test that a decoder panicking on a payload is logged as an error and does
+// not stop the other decoders
+func TestLogDataDecoderPanic(t *testing.T) {
+	decoders, err := NewDecoders(ListenerConfig{Decoders: []string{"asterix"}})
+	if err != nil {
+		t.Fatalf("NewDecoders() error = %v", err)
+	}
+	logFile := filepath.Join(t.TempDir(), "debug.log")
+	logger, err := NewRotatingLogger(logFile, LogLevelDebug, BinaryEncodingHex, append([]Decoder{panicDecoder{}}, decoders...))
+	if err != nil {
+		t.Fatalf("NewRotatingLogger() error = %v", err)
+	}
+	defer logger.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := logger.LogData("127.0.0.1", 8600, "udp", []byte{0x30, 0x00, 0x06, 0x80, 0x01, 0x02}); err != nil {
+			t.Fatalf("LogData() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read log: %v", err)
+	}
+	var entry struct {
+		Decoded map[string]json.RawMessage `json:"decoded"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(&entry); err != nil {
+		t.Fatalf("failed to decode log entry: %v", err)
+	}
+	if got := string(entry.Decoded["panic"]); got != `{"error":"decoder panicked: assignment to entry in nil map"}` {
+		t.Errorf("decoded panic = %s", got)
+	}
+	if _, ok := entry.Decoded["asterix"]; !ok {
+		t.Errorf("decoded = %v, want the asterix decoder to run too", entry.Decoded)
+	}
+}
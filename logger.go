@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
@@ -18,14 +19,21 @@ const (
 
 // LogEntry represents a debug-level log entry
 type LogEntry struct {
-	Timestamp  string          `json:"timestamp"`
-	SourceIP   string          `json:"source_ip"`
-	SourcePort int             `json:"source_port"`
-	Protocol   string          `json:"protocol"`
-	Payload    string          `json:"payload"`
-	PayloadLen int             `json:"payload_len"`
-	Encoding   string          `json:"encoding"`          // "ascii", "utf8", or "base64"
-	Asterix    *AsterixMessage `json:"asterix,omitempty"` // Decoded ASTERIX data if detected
+	Timestamp  string                 `json:"timestamp"`
+	SourceIP   string                 `json:"source_ip"`
+	SourcePort int                    `json:"source_port"`
+	Protocol   string                 `json:"protocol"`
+	Payload    string                 `json:"payload"`
+	PayloadLen int                    `json:"payload_len"`
+	Encoding   string                 `json:"encoding"`           // "ascii", "utf8", or "base64"
+	Detected   map[string]float64     `json:"detected,omitempty"` // Detection confidence keyed by decoder name
+	Decoded    map[string]interface{} `json:"decoded,omitempty"`  // Decoded payload keyed by decoder name
+}
+
+// SummaryEntry is a periodic DEBUG-level log entry holding a decoder summary
+type SummaryEntry struct {
+	Timestamp string                 `json:"timestamp"`
+	Summary   map[string]interface{} `json:"summary"` // keyed by decoder name
 }
 
 // RotatingLogger handles log writing with automatic rotation
@@ -33,6 +41,7 @@ type RotatingLogger struct {
 	filename       string
 	logLevel       LogLevel
 	binaryEncoding BinaryEncoding
+	decoders       []Decoder
 	file           *os.File
 	currentSize    int64
 	lastRotation   time.Time
@@ -42,11 +51,12 @@ type RotatingLogger struct {
 }
 
 // NewRotatingLogger creates a new rotating logger
-func NewRotatingLogger(filename string, logLevel LogLevel, binaryEncoding BinaryEncoding) (*RotatingLogger, error) {
+func NewRotatingLogger(filename string, logLevel LogLevel, binaryEncoding BinaryEncoding, decoders []Decoder) (*RotatingLogger, error) {
 	logger := &RotatingLogger{
 		filename:       filename,
 		logLevel:       logLevel,
 		binaryEncoding: binaryEncoding,
+		decoders:       decoders,
 		lastRotation:   time.Now(),
 		stopChan:       make(chan struct{}),
 	}
@@ -60,9 +70,29 @@ func NewRotatingLogger(filename string, logLevel LogLevel, binaryEncoding Binary
 	logger.rotationTicker = time.NewTicker(1 * time.Minute)
 	go logger.checkRotation()
 
+	// Start the summaries of decoders that report them
+	for _, decoder := range decoders {
+		if summarizer, ok := decoder.(Summarizer); ok && summarizer.SummaryInterval() > 0 {
+			if logLevel != LogLevelDebug {
+				fmt.Printf("Decoder %s summaries are only logged at DEBUG, so %s will not include them\n", decoder.Name(), filename)
+			}
+			go logger.logSummaries(decoder.Name(), summarizer)
+		}
+	}
+
 	return logger, nil
 }
 
+// NewListenerLogger creates the rotating logger and decoders for a listener
+func NewListenerLogger(config ListenerConfig) (*RotatingLogger, error) {
+	decoders, err := NewDecoders(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRotatingLogger(config.LogFile, config.LogLevel, config.BinaryEncoding, decoders)
+}
+
 // openExisting opens an existing log file or creates a new one
 func (rl *RotatingLogger) openExisting() error {
 	// Create directory if it doesn't exist
@@ -121,6 +151,48 @@ func (rl *RotatingLogger) checkRotation() {
 	}
 }
 
+// logSummaries periodically writes a decoder's summary until the logger is
+// closed. Summaries are computed at every log level so that each covers one
+// interval, but only DEBUG logs include them.
+func (rl *RotatingLogger) logSummaries(name string, summarizer Summarizer) {
+	ticker := time.NewTicker(summarizer.SummaryInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			summary := summarizer.Summary(now)
+			if rl.logLevel != LogLevelDebug {
+				continue
+			}
+
+			entry := SummaryEntry{
+				Timestamp: now.Format(time.RFC3339),
+				Summary:   map[string]interface{}{name: summary},
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				fmt.Printf("Failed to marshal %s summary: %v\n", name, err)
+				continue
+			}
+
+			rl.mu.Lock()
+			select {
+			case <-rl.stopChan:
+				// Closed while the summary was computed
+			default:
+				err = rl.write(append(data, '\n'))
+			}
+			rl.mu.Unlock()
+			if err != nil {
+				fmt.Printf("Failed to log %s summary: %v\n", name, err)
+			}
+		case <-rl.stopChan:
+			return
+		}
+	}
+}
+
 // rotate closes the current file and opens a new one
 func (rl *RotatingLogger) rotate() error {
 	// Close existing file
@@ -216,6 +288,9 @@ func encodeHex(payload []byte) string {
 
 // LogData logs data based on the configured log level
 func (rl *RotatingLogger) LogData(sourceIP string, sourcePort int, protocol string, payload []byte) error {
+	// Take the receive time before waiting for other connections' writes
+	received := time.Now()
+
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
@@ -223,13 +298,19 @@ func (rl *RotatingLogger) LogData(sourceIP string, sourcePort int, protocol stri
 	var err error
 
 	if rl.logLevel == LogLevelData {
-		// DATA mode: just log the payload
+		// DATA mode: just log the payload, decoding it only to keep the
+		// state of monitoring decoders current
+		for _, decoder := range rl.decoders {
+			if monitor, ok := decoder.(Monitor); ok && monitor.Monitoring() {
+				runDecoder(decoder, payload, received)
+			}
+		}
 		logData = append(payload, '\n')
 	} else {
 		// DEBUG mode: log JSON with metadata
 		encodedPayload, encoding := encodePayload(payload, rl.binaryEncoding)
 		entry := LogEntry{
-			Timestamp:  time.Now().Format(time.RFC3339),
+			Timestamp:  received.Format(time.RFC3339),
 			SourceIP:   sourceIP,
 			SourcePort: sourcePort,
 			Protocol:   protocol,
@@ -238,10 +319,21 @@ func (rl *RotatingLogger) LogData(sourceIP string, sourcePort int, protocol stri
 			Encoding:   encoding,
 		}
 
-		// Check if payload appears to be ASTERIX and decode it
-		if isAsterixMessage(payload) {
-			asterixData := decodeAsterixMessage(payload)
-			entry.Asterix = asterixData
+		// Run every decoder that recognises the payload
+		for _, decoder := range rl.decoders {
+			confidence, decoded := runDecoder(decoder, payload, received)
+			if decoded == nil {
+				continue
+			}
+			if entry.Detected == nil {
+				entry.Detected = make(map[string]float64)
+			}
+			entry.Detected[decoder.Name()] = confidence
+
+			if entry.Decoded == nil {
+				entry.Decoded = make(map[string]interface{})
+			}
+			entry.Decoded[decoder.Name()] = decoded
 		}
 
 		logData, err = json.Marshal(entry)
@@ -251,7 +343,35 @@ func (rl *RotatingLogger) LogData(sourceIP string, sourcePort int, protocol stri
 		logData = append(logData, '\n')
 	}
 
-	// Write to file
+	return rl.write(logData)
+}
+
+// runDecoder detects and decodes a payload with one decoder, returning a nil
+// value when the decoder does not recognise it. Errors, and panics on
+// malformed payloads, are returned as an error value so that they are logged
+// instead of stopping the listener.
+func runDecoder(decoder Decoder, payload []byte, received time.Time) (confidence float64, decoded interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Decoder %s panicked on a %d octet payload: %v\n", decoder.Name(), len(payload), r)
+			decoded = map[string]string{"error": fmt.Sprintf("decoder panicked: %v", r)}
+		}
+	}()
+
+	confidence = decoder.Detect(payload)
+	if confidence <= 0 {
+		return 0, nil
+	}
+	value, err := decoder.Decode(payload, received)
+	if err != nil {
+		return confidence, map[string]string{"error": err.Error()}
+	}
+	return confidence, value
+}
+
+// write appends a line to the log file, rotating it when it grows too large.
+// The caller must hold the lock.
+func (rl *RotatingLogger) write(logData []byte) error {
 	n, err := rl.file.Write(logData)
 	if err != nil {
 		return fmt.Errorf("failed to write to log file: %w", err)
@@ -277,6 +397,15 @@ func (rl *RotatingLogger) Close() error {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
+	// Decoders holding files or goroutines release them with the logger
+	for _, decoder := range rl.decoders {
+		if closer, ok := decoder.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				fmt.Printf("Failed to close %s decoder: %v\n", decoder.Name(), err)
+			}
+		}
+	}
+
 	if rl.file != nil {
 		return rl.file.Close()
 	}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+
+	"good-listener/asterix"
 )
 
 // Listener interface for all listener types
@@ -17,6 +19,7 @@ type Listener interface {
 func main() {
 	// Parse command-line flags
 	configFile := flag.String("config", "config.yaml", "Path to configuration file")
+	encodeFile := flag.String("encode", "", "Encode the ASTERIX message in this JSON file to stdout and exit")
 	flag.Parse()
 
 	// Load configuration
@@ -26,6 +29,40 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Load site-specific ASTERIX category specifications
+	if config.AsterixSpecDir != "" {
+		if err := asterix.LoadSpecDir(config.AsterixSpecDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading ASTERIX specifications: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Load the layouts of Reserved Expansion and Special Purpose fields,
+	// which apply to the specifications loaded above
+	if config.AsterixExpansionDir != "" {
+		if err := asterix.LoadExpansionDir(config.AsterixExpansionDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading ASTERIX expansion fields: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Load the names and expected traffic of the data sources
+	if config.SourceRegistry != "" {
+		if err := LoadSourceRegistry(config.SourceRegistry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading source registry: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Generate test traffic instead of listening
+	if *encodeFile != "" {
+		if err := encodeAsterixFile(*encodeFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding ASTERIX message: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Create listeners based on configuration
 	var listeners []Listener
 	for _, listenerConfig := range config.Listeners {
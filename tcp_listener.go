@@ -43,7 +43,7 @@ type TCPListener struct {
 
 // NewTCPListener creates a new TCP listener
 func NewTCPListener(config ListenerConfig) (*TCPListener, error) {
-	logger, err := NewRotatingLogger(config.LogFile, config.LogLevel, config.BinaryEncoding)
+	logger, err := NewListenerLogger(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
@@ -143,7 +143,7 @@ type TLSListener struct {
 
 // NewTLSListener creates a new TLS listener
 func NewTLSListener(config ListenerConfig) (*TLSListener, error) {
-	logger, err := NewRotatingLogger(config.LogFile, config.LogLevel, config.BinaryEncoding)
+	logger, err := NewListenerLogger(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}
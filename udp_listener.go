@@ -15,7 +15,7 @@ type UDPListener struct {
 
 // NewUDPListener creates a new UDP listener
 func NewUDPListener(config ListenerConfig) (*UDPListener, error) {
-	logger, err := NewRotatingLogger(config.LogFile, config.LogLevel, config.BinaryEncoding)
+	logger, err := NewListenerLogger(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create logger: %w", err)
 	}